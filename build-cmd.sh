@@ -37,7 +37,7 @@ if [ x"$mode"x = x"modbuild"x ]; then
                 RC=$? ; if [ $RC -ne 0 ]; then break ; fi
             fi
 
-            go vet
+            go vet ./...
             RC=$? ; if [ $RC -ne 0 ]; then break ; fi
 
             go install -ldflags "-X main.version=$(git describe)"
@@ -0,0 +1,362 @@
+//
+// configuration file (.yamlsort.yaml)
+//
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/george-pon/yamlsort/src/yamlsort/pkg/yamlsort"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// configuration file name. it is searched from directory of input file to root directory.
+const configFileName = ".yamlsort.yaml"
+
+// flags which can not be written in configuration file
+var configDenyKeys = map[string]bool{
+	"input-file":        true,
+	"output-file":       true,
+	"input-output-file": true,
+	"output-dir":        true,
+	"check":             true,
+	"diff":              true,
+	"version":           true,
+	"print-config":      true,
+	"help":              true,
+}
+
+//---------------------------------------------------------------------
+//  configFile class
+// settings are flag name and values. overrides are applied to files matching glob patterns.
+//
+type configFile struct {
+	path      string
+	settings  map[string][]string
+	overrides []configOverride
+}
+
+type configOverride struct {
+	files    []string
+	settings map[string][]string
+}
+
+// convert config value (scalar or list) to flag values
+func configValues(key string, value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			itemValues, err := configValues(key, item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, itemValues...)
+		}
+		return values, nil
+	case map[string]interface{}:
+		return nil, fmt.Errorf("value of %v must be scalar or list", key)
+	case nil:
+		return []string{}, nil
+	case string:
+		return []string{v}, nil
+	case int:
+		return []string{strconv.Itoa(v)}, nil
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// convert config map to settings. key must be flag name.
+func (c *yamlsortCmd) configSettings(m map[string]interface{}) (map[string][]string, error) {
+	settings := map[string][]string{}
+	for key, value := range m {
+		if key == "overrides" {
+			continue
+		}
+		if c.flags.Lookup(key) == nil || configDenyKeys[key] {
+			return nil, fmt.Errorf("unknown key: %v", key)
+		}
+		values, err := configValues(key, value)
+		if err != nil {
+			return nil, err
+		}
+		settings[key] = values
+	}
+	return settings, nil
+}
+
+// load configuration file
+func (c *yamlsortCmd) loadConfigFile(path string) (*configFile, error) {
+	readBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal(readBytes, &m); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	cfg := &configFile{path: path}
+	cfg.settings, err = c.configSettings(m)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	// overrides:
+	// - files: [ "charts/**/values.yaml" ]
+	//   key: [ ... ]
+	if overrides, ok := m["overrides"]; ok {
+		list, ok := overrides.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v: overrides must be list", path)
+		}
+		for _, item := range list {
+			om, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%v: item of overrides must be map", path)
+			}
+			files, err := configValues("files", om["files"])
+			if err != nil || len(files) == 0 {
+				return nil, fmt.Errorf("%v: item of overrides needs files", path)
+			}
+			delete(om, "files")
+			settings, err := c.configSettings(om)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", path, err)
+			}
+			cfg.overrides = append(cfg.overrides, configOverride{files: files, settings: settings})
+		}
+	}
+	return cfg, nil
+}
+
+// return settings for file. later override replaces earlier setting of same key.
+func (cfg *configFile) settingsFor(filename string) map[string][]string {
+	settings := map[string][]string{}
+	for k, v := range cfg.settings {
+		settings[k] = v
+	}
+	abspath, err := filepath.Abs(filename)
+	if err != nil || len(filename) == 0 {
+		return settings
+	}
+	rel, err := filepath.Rel(filepath.Dir(cfg.path), abspath)
+	if err != nil {
+		return settings
+	}
+	for _, override := range cfg.overrides {
+		if !matchAnyPattern(override.files, rel) {
+			continue
+		}
+		for k, v := range override.settings {
+			settings[k] = v
+		}
+	}
+	return settings
+}
+
+//---------------------------------------------------------------------
+//  configCache class
+// loaded configuration file of each directory. it is shared by workers.
+//
+type configCache struct {
+	mutex sync.Mutex
+	dirs  map[string]*configFile
+	errs  map[string]error
+}
+
+func newConfigCache() *configCache {
+	return &configCache{dirs: map[string]*configFile{}, errs: map[string]error{}}
+}
+
+// return nearest configuration file of file. filename "" means current directory. nil if not found.
+func (c *yamlsortCmd) configFor(filename string) (*configFile, error) {
+	dir := "."
+	if len(filename) > 0 {
+		dir = filepath.Dir(filename)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	c.configs.mutex.Lock()
+	defer c.configs.mutex.Unlock()
+	searched := []string{}
+	var cfg *configFile
+	for {
+		if found, ok := c.configs.dirs[dir]; ok {
+			cfg, err = found, c.configs.errs[dir]
+			break
+		}
+		searched = append(searched, dir)
+		path := filepath.Join(dir, configFileName)
+		if _, statErr := os.Stat(path); statErr == nil {
+			cfg, err = c.loadConfigFile(path)
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	for _, d := range searched {
+		c.configs.dirs[d] = cfg
+		c.configs.errs[d] = err
+	}
+	return cfg, err
+}
+
+//-------------------------------------------------------------------------------------
+//  return command for file. settings are flag default , configuration file and command line flags , in this order.
+//
+func (c *yamlsortCmd) fileCmd(filename string) (*yamlsortCmd, *configFile, error) {
+	fc, _, cfg, err := c.fileFlagSet(filename)
+	return fc, cfg, err
+}
+
+// return command for file and its flag set
+func (c *yamlsortCmd) fileFlagSet(filename string) (*yamlsortCmd, *pflag.FlagSet, *configFile, error) {
+	cfg, err := c.configFor(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fc := &yamlsortCmd{
+		stdin:         c.stdin,
+		stdout:        c.stdout,
+		stderr:        c.stderr,
+		version:       c.version,
+		flags:         c.flags,
+		cmdargs:       c.cmdargs,
+		configs:       c.configs,
+		overrideStdin: c.overrideStdin,
+	}
+	fs := pflag.NewFlagSet("yamlsort", pflag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fc.addFlags(fs)
+
+	// configuration file. flags in command line are prior.
+	if cfg != nil {
+		for key, values := range cfg.settingsFor(filename) {
+			if c.flags.Changed(key) {
+				continue
+			}
+			for _, value := range values {
+				if err := fs.Set(key, cfg.resolvePath(key, value)); err != nil {
+					return nil, nil, nil, fmt.Errorf("%v: %v: %v", cfg.path, key, err)
+				}
+			}
+		}
+	}
+
+	// command line flags
+	if err := fs.Parse(c.cmdargs); err != nil {
+		return nil, nil, nil, err
+	}
+	return fc, fs, cfg, nil
+}
+
+// resolve relative file path of --override-file , --set-file in configuration file against directory of it
+func (cfg *configFile) resolvePath(key string, value string) string {
+	dir := filepath.Dir(cfg.path)
+	switch key {
+	case "override-file":
+		if value == "-" || filepath.IsAbs(value) {
+			return value
+		}
+		return filepath.Join(dir, value)
+	case "set-file":
+		set, err := yamlsort.ParseSetValue(value, yamlsort.SetTypeString)
+		if err != nil || filepath.IsAbs(set.Value) {
+			return value
+		}
+		return set.Path + "=" + filepath.Join(dir, set.Value)
+	}
+	return value
+}
+
+// print effective settings of file
+func (c *yamlsortCmd) printConfig(filename string) error {
+	_, fs, cfg, err := c.fileFlagSet(filename)
+	if err != nil {
+		return err
+	}
+	settings := map[string]interface{}{}
+	fs.VisitAll(func(f *pflag.Flag) {
+		if configDenyKeys[f.Name] {
+			return
+		}
+		switch f.Value.Type() {
+		case "stringArray":
+			values, _ := fs.GetStringArray(f.Name)
+			list := []interface{}{}
+			for _, v := range values {
+				list = append(list, v)
+			}
+			settings[f.Name] = list
+		case "bool":
+			settings[f.Name] = f.Value.String() == "true"
+		case "int":
+			settings[f.Name], _ = strconv.Atoi(f.Value.String())
+		default:
+			settings[f.Name] = f.Value.String()
+		}
+	})
+	out, err := yamlsort.Marshal(settings, yamlsort.Options{})
+	if err != nil {
+		return err
+	}
+	if len(filename) == 0 {
+		filename = "<stdin>"
+	}
+	cfgname := "none"
+	if cfg != nil {
+		cfgname = cfg.path
+	}
+	fmt.Fprintln(c.stdout, "---")
+	fmt.Fprintf(c.stdout, "# %s  # config: %s\n", filename, cfgname)
+	fmt.Fprint(c.stdout, string(out))
+	return nil
+}
+
+// return command for input file. jobs is same as c , because files are already processed in parallel.
+func (c *yamlsortCmd) configCmd(filename string) (*yamlsortCmd, error) {
+	if c.flags == nil {
+		return c, nil
+	}
+	fc, _, err := c.fileCmd(filename)
+	if err != nil {
+		return nil, err
+	}
+	fc.jobs = c.jobs
+	if err := fc.checkFlags(); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+//-------------------------------------------------------------------------------------
+//  --print-config. print effective settings of each input file (or stdin).
+//
+func (c *yamlsortCmd) runPrintConfig(args []string) error {
+	if len(args) == 0 {
+		return c.printConfig(c.inputfilename)
+	}
+	files, err := c.collectFiles(args)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := c.printConfig(file.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+//
+// unified diff of input text and myMarshal output text
+//
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// number of context lines in unified diff
+const diffContextLines = 3
+
+// ANSI escape sequence for --color
+const (
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+// kind of diff line
+const (
+	diffEqual = iota
+	diffDelete
+	diffInsert
+)
+
+//---------------------------------------------------------------------
+//  diffLine class
+// one line of edit script. index is line index in old text (delete, equal) or new text (insert).
+//
+type diffLine struct {
+	kind    int
+	oldLine int
+	newLine int
+	text    string
+}
+
+// split text into lines. trailing newline does not make empty last line.
+// last line without newline keeps "\n" at the end , so that it differs from same line with newline.
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return []string{}
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = lines[len(lines)-1] + "\n"
+	}
+	return lines
+}
+
+// compute edit script from a to b, with Myers O(ND) diff algorithm.
+func diffLines(a []string, b []string) []diffLine {
+	// common prefix and suffix are equal lines. no need to search.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	result := []diffLine{}
+	for i := 0; i < prefix; i++ {
+		result = append(result, diffLine{kind: diffEqual, oldLine: i, newLine: i, text: a[i]})
+	}
+	result = append(result, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)...)
+	for i := suffix; i > 0; i-- {
+		result = append(result, diffLine{kind: diffEqual, oldLine: len(a) - i, newLine: len(b) - i, text: a[len(a)-i]})
+	}
+	return deleteFirst(result)
+}
+
+// in each run of changed lines , deleted lines are written before inserted lines
+func deleteFirst(script []diffLine) []diffLine {
+	result := make([]diffLine, 0, len(script))
+	i := 0
+	for i < len(script) {
+		if script[i].kind == diffEqual {
+			result = append(result, script[i])
+			i++
+			continue
+		}
+		oldStart, newStart := script[i].oldLine, script[i].newLine
+		deletes := []diffLine{}
+		inserts := []diffLine{}
+		for ; i < len(script) && script[i].kind != diffEqual; i++ {
+			if script[i].kind == diffDelete {
+				deletes = append(deletes, script[i])
+			} else {
+				inserts = append(inserts, script[i])
+			}
+		}
+		for _, line := range deletes {
+			line.newLine = newStart
+			result = append(result, line)
+		}
+		for _, line := range inserts {
+			line.oldLine = oldStart + len(deletes)
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// compute edit script of a and b , with linear space variant of Myers diff.
+// middle snake of shortest edit path divides a and b , and each half is computed recursively.
+func diffMiddle(a []string, b []string, oldOffset int, newOffset int) []diffLine {
+	result := []diffLine{}
+	diffRecursive(a, b, oldOffset, newOffset, &result)
+	return result
+}
+
+func diffRecursive(a []string, b []string, oldOffset int, newOffset int, result *[]diffLine) {
+	// common prefix
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		*result = append(*result, diffLine{kind: diffEqual, oldLine: oldOffset, newLine: newOffset, text: a[0]})
+		a, b = a[1:], b[1:]
+		oldOffset++
+		newOffset++
+	}
+	// common suffix is appended after middle
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	n := len(a) - suffix
+	m := len(b) - suffix
+	switch {
+	case n == 0:
+		for y := 0; y < m; y++ {
+			*result = append(*result, diffLine{kind: diffInsert, oldLine: oldOffset, newLine: newOffset + y, text: b[y]})
+		}
+	case m == 0:
+		for x := 0; x < n; x++ {
+			*result = append(*result, diffLine{kind: diffDelete, oldLine: oldOffset + x, newLine: newOffset, text: a[x]})
+		}
+	default:
+		x, y, u, v := middleSnake(a[:n], b[:m])
+		diffRecursive(a[:x], b[:y], oldOffset, newOffset, result)
+		for i := 0; i < u-x; i++ {
+			*result = append(*result, diffLine{kind: diffEqual, oldLine: oldOffset + x + i, newLine: newOffset + y + i, text: a[x+i]})
+		}
+		diffRecursive(a[u:n], b[v:m], oldOffset+u, newOffset+v, result)
+	}
+	for i := 0; i < suffix; i++ {
+		*result = append(*result, diffLine{kind: diffEqual, oldLine: oldOffset + n + i, newLine: newOffset + m + i, text: a[n+i]})
+	}
+}
+
+// return middle snake (x,y)-(u,v) of shortest edit path from a to b.
+// forward path from start and backward path from end are searched , until they overlap.
+func middleSnake(a []string, b []string) (x int, y int, u int, v int) {
+	n := len(a)
+	m := len(b)
+	delta := n - m
+	blnOdd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	// x of furthest path on diagonal k. backward x is counted from the end.
+	vf := make([]int, 2*max+3)
+	vb := make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		// forward
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y = x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			kb := delta - k
+			if blnOdd && kb >= -(d-1) && kb <= d-1 && x+vb[offset+kb] >= n {
+				return startX, startY, x, y
+			}
+		}
+		// backward
+		for kb := -d; kb <= d; kb += 2 {
+			var xb int
+			if kb == -d || (kb != d && vb[offset+kb-1] < vb[offset+kb+1]) {
+				xb = vb[offset+kb+1]
+			} else {
+				xb = vb[offset+kb-1] + 1
+			}
+			yb := xb - kb
+			startXb, startYb := xb, yb
+			for xb < n && yb < m && a[n-1-xb] == b[m-1-yb] {
+				xb++
+				yb++
+			}
+			vb[offset+kb] = xb
+			k := delta - kb
+			if !blnOdd && k >= -d && k <= d && vf[offset+k]+xb >= n {
+				return n - xb, m - yb, n - startXb, m - startYb
+			}
+		}
+	}
+	// not reached. all lines of a are deleted , and all lines of b are inserted.
+	return n, 0, n, 0
+}
+
+// return hunk line range string like "3,5" , "3" (one line) , "2,0" (no line)
+func hunkRange(start int, length int) string {
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// return label of document which contains line, like "document 2". output document starts with "---" line.
+func documentLabel(lines []string, line int) string {
+	count := 0
+	for i := 0; i <= line && i < len(lines); i++ {
+		if lines[i] == "---" {
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" document %d", count)
+}
+
+//-------------------------------------------------------------------------------------
+//  write unified diff of oldText and newText. return true if they differ.
+//
+func writeUnifiedDiff(writer io.Writer, name string, oldText []byte, newText []byte, blnColor bool) bool {
+	if string(oldText) == string(newText) {
+		return false
+	}
+	oldLines := splitLines(string(oldText))
+	newLines := splitLines(string(newText))
+	script := diffLines(oldLines, newLines)
+
+	color := func(code string, s string) string {
+		if !blnColor || len(code) == 0 {
+			return s
+		}
+		return code + s + colorReset
+	}
+	writeLine := func(code string, mark string, text string) {
+		fmt.Fprintln(writer, color(code, mark+strings.TrimSuffix(text, "\n")))
+		if strings.HasSuffix(text, "\n") {
+			fmt.Fprintln(writer, "\\ No newline at end of file")
+		}
+	}
+
+	fmt.Fprintln(writer, color(colorBold, "--- a/"+name))
+	fmt.Fprintln(writer, color(colorBold, "+++ b/"+name))
+
+	// make hunks. changes closer than 2 * context lines are in same hunk.
+	i := 0
+	for i < len(script) {
+		if script[i].kind == diffEqual {
+			i++
+			continue
+		}
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(script) {
+			if script[end].kind != diffEqual {
+				end++
+				continue
+			}
+			// count equal lines
+			next := end
+			for next < len(script) && script[next].kind == diffEqual {
+				next++
+			}
+			if next < len(script) && next-end <= 2*diffContextLines {
+				end = next
+				continue
+			}
+			end = end + diffContextLines
+			if end > len(script) {
+				end = len(script)
+			}
+			break
+		}
+
+		// hunk header
+		oldStart, newStart := script[start].oldLine, script[start].newLine
+		oldLen, newLen := 0, 0
+		for _, line := range script[start:end] {
+			switch line.kind {
+			case diffEqual:
+				oldLen++
+				newLen++
+			case diffDelete:
+				oldLen++
+			case diffInsert:
+				newLen++
+			}
+		}
+		header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(oldStart, oldLen), hunkRange(newStart, newLen))
+		fmt.Fprintln(writer, color(colorCyan, header)+documentLabel(newLines, newStart))
+
+		// hunk body
+		for _, line := range script[start:end] {
+			switch line.kind {
+			case diffEqual:
+				writeLine("", " ", line.text)
+			case diffDelete:
+				writeLine(colorRed, "-", line.text)
+			case diffInsert:
+				writeLine(colorGreen, "+", line.text)
+			}
+		}
+		i = end
+	}
+	return true
+}
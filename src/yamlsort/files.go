@@ -0,0 +1,250 @@
+//
+// collect input files from file names, glob patterns and directory trees
+//
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//---------------------------------------------------------------------
+//  inputFile class
+// path is file path to read. rel is relative path from current directory, used in --output-dir.
+//
+type inputFile struct {
+	path string
+	rel  string
+}
+
+// default file name pattern in directory walking
+var defaultIncludeYAML = []string{"*.yaml", "*.yml"}
+var defaultIncludeJSON = []string{"*.json"}
+
+// return true if s has glob meta character
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// convert glob pattern to regexp. "**" matches any number of directories, "*" and "?" do not match "/".
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	buf := new(strings.Builder)
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			buf.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			buf.WriteString(".*")
+			i++
+		case ch == '*':
+			buf.WriteString("[^/]*")
+		case ch == '?':
+			buf.WriteString("[^/]")
+		case ch == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("bad pattern:%v", pattern)
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + class + "]")
+			i += end
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
+
+// match path with glob pattern. pattern without "/" is matched with file name (base name) only.
+// relative pattern like "skip/**" matches in any directory.
+func matchPathPattern(pattern string, path string) bool {
+	path = filepath.ToSlash(filepath.Clean(path))
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := filepath.Match(pattern, filepath.Base(path))
+		return ok
+	}
+	if !strings.HasPrefix(pattern, "/") && !strings.HasPrefix(pattern, "**/") {
+		pattern = "**/" + pattern
+	}
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
+
+// return true if path matches one of patterns
+func matchAnyPattern(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if len(pattern) > 0 && matchPathPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// check include/exclude pattern. file given explicitly is always included unless excluded.
+// patterns in .yamlsort.yaml of the file are used , if they are not given in command line.
+func (c *yamlsortCmd) checkIncludeFile(path string, blnExplicit bool) bool {
+	if fc, err := c.configCmd(path); err == nil {
+		c = fc
+	}
+	if matchAnyPattern(c.excludes, path) {
+		return false
+	}
+	if blnExplicit {
+		return true
+	}
+	includes := c.includes
+	if len(includes) == 0 {
+		includes = defaultIncludeYAML
+		if c.blnInputJSON {
+			includes = defaultIncludeJSON
+		}
+	}
+	return matchAnyPattern(includes, path)
+}
+
+// return relative path from current directory
+func relativePath(path string) (string, error) {
+	abspath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(cwd, abspath)
+	if err != nil {
+		return "", err
+	}
+	return rel, nil
+}
+
+// return static directory part of glob pattern , like "k8s" for "k8s/**/*.yaml"
+func globRoot(pattern string) string {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	var root []string
+	for _, s := range segments {
+		if hasGlobMeta(s) {
+			break
+		}
+		root = append(root, s)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	if len(root) == 1 && root[0] == "" {
+		return "/"
+	}
+	return filepath.FromSlash(strings.Join(root, "/"))
+}
+
+//-------------------------------------------------------------------------------------
+//  collect input files from arguments.
+//  argument is file name, glob pattern (with ** support) or directory (with --recursive).
+//
+func (c *yamlsortCmd) collectFiles(args []string) ([]inputFile, error) {
+	result := []inputFile{}
+	done := map[string]bool{}
+
+	add := func(path string, blnExplicit bool, matchPath string) error {
+		path = filepath.Clean(path)
+		if done[path] || !c.checkIncludeFile(matchPath, blnExplicit) {
+			return nil
+		}
+		done[path] = true
+		rel, err := relativePath(path)
+		if err != nil {
+			return err
+		}
+		result = append(result, inputFile{path: path, rel: rel})
+		return nil
+	}
+
+	// walk directory tree. .git directory and configuration file are skipped.
+	walk := func(root string, fn func(path string) error) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if info.Name() == ".git" && path != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Name() == configFileName {
+				return nil
+			}
+			return fn(path)
+		})
+	}
+
+	for _, arg := range args {
+		if hasGlobMeta(arg) {
+			// glob pattern
+			re, err := globToRegexp(strings.TrimPrefix(filepath.ToSlash(arg), "./"))
+			if err != nil {
+				return result, err
+			}
+			root := globRoot(arg)
+			count := 0
+			err = walk(root, func(path string) error {
+				if !re.MatchString(strings.TrimPrefix(filepath.ToSlash(path), "./")) {
+					return nil
+				}
+				count++
+				return add(path, true, path)
+			})
+			if err != nil && !os.IsNotExist(err) {
+				return result, err
+			}
+			if count == 0 {
+				return result, fmt.Errorf("no file matches pattern:%v", arg)
+			}
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			return result, err
+		}
+		if !info.IsDir() {
+			// file name
+			err = add(arg, true, arg)
+			if err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		// directory
+		if !c.blnRecursive {
+			return result, fmt.Errorf("%v is a directory. use --recursive option", arg)
+		}
+		err = walk(arg, func(path string) error {
+			rel, err := filepath.Rel(arg, path)
+			if err != nil {
+				return err
+			}
+			return add(path, false, rel)
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -1,11 +1,12 @@
-module yamlsort
+module github.com/george-pon/yamlsort/src/yamlsort
 
 require (
 	github.com/ghodss/yaml v1.0.0
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/spf13/cobra v0.0.3
-	github.com/spf13/pflag v1.0.3 // indirect
-	gopkg.in/yaml.v2 v2.2.2 // indirect
+	github.com/spf13/pflag v1.0.3
+	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 go 1.13
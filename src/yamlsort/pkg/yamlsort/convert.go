@@ -0,0 +1,320 @@
+//
+// convert between yaml node and plain data
+//
+package yamlsort
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//-------------------------------------------------------------------------
+// unmarshal yaml/json data to yaml node
+//
+func (c *sorter) myUnmarshal(inputbytes []byte) (*yaml.Node, error) {
+	data := &yaml.Node{}
+
+	if c.blnInputJSON {
+		// parse json data
+		var jsondata interface{}
+		decoder := json.NewDecoder(bytes.NewReader(inputbytes))
+		decoder.UseNumber()
+		err := decoder.Decode(&jsondata)
+		if err != nil {
+			return data, err
+		}
+		data, err = c.myDataToNode(jsondata)
+		if err != nil {
+			return data, err
+		}
+	} else {
+		// parse yaml data
+		err := yaml.Unmarshal(normalizeYAMLInput(inputbytes), data)
+		if err != nil {
+			return data, err
+		}
+		c.resolvePlainScalars(data)
+	}
+	return data, nil
+}
+
+// convert plain data (json unmarshal result) to yaml document node
+func (c *sorter) myDataToNode(data interface{}) (*yaml.Node, error) {
+	content, err := c.myDataToNodeRecursive(data)
+	if err != nil {
+		return nil, err
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{content}}, nil
+}
+
+func (c *sorter) myDataToNodeRecursive(data interface{}) (*yaml.Node, error) {
+	if m, ok := data.(map[string]interface{}); ok {
+		// data is map
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		var keylist []string
+		for k := range m {
+			keylist = append(keylist, k)
+		}
+		sort.Strings(keylist)
+		for _, k := range keylist {
+			value, err := c.myDataToNodeRecursive(m[k])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, value)
+		}
+		return node, nil
+	} else if a, ok := data.([]interface{}); ok {
+		// data is slice
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, v := range a {
+			value, err := c.myDataToNodeRecursive(v)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		return node, nil
+	} else if n, ok := data.(json.Number); ok {
+		// data is number. keep number as written in json.
+		tag := "!!int"
+		if strings.ContainsAny(n.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: n.String()}, nil
+	}
+	if str, ok := data.(string); ok {
+		// data is string
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: str}, nil
+	} else if b, ok := data.(bool); ok {
+		// data is bool
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+	} else if data == nil {
+		// data is null
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	} else if _, ok := data.(*yaml.Node); !ok {
+		// other go data (int , struct , map[string]string , ...) is converted via json
+		jsonbytes, err := json.Marshal(data)
+		if err == nil {
+			decoder := json.NewDecoder(bytes.NewReader(jsonbytes))
+			decoder.UseNumber()
+			var jsondata interface{}
+			if err := decoder.Decode(&jsondata); err == nil {
+				return c.myDataToNodeRecursive(jsondata)
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown type:%v  data:%v", reflect.TypeOf(data), data)
+}
+
+//-------------------------------------------------------------------------
+// convert yaml node to plain data (map[string]interface{}, []interface{}, string, ...)
+//
+func (c *sorter) myNodeToData(node *yaml.Node) (interface{}, error) {
+	node = resolveAlias(node)
+	if node == nil {
+		return nil, nil
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return c.myNodeToData(node.Content[0])
+	case yaml.MappingNode:
+		result := map[string]interface{}{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			v := node.Content[i+1]
+			if k.Tag == "!!merge" {
+				// merge key (<<) . explicit key is prior to merged key.
+				err := c.myMergeToData(result, v)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			value, err := c.myNodeToData(v)
+			if err != nil {
+				return nil, err
+			}
+			result[k.Value] = value
+		}
+		return result, nil
+	case yaml.SequenceNode:
+		result := []interface{}{}
+		for _, v := range node.Content {
+			value, err := c.myNodeToData(v)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	case yaml.ScalarNode:
+		if node.Tag == "!!int" || node.Tag == "!!float" {
+			return myNumberNodeToData(node)
+		}
+		return myScalarNodeToData(node)
+	}
+	return nil, fmt.Errorf("unknown node kind:%v  data:%v", node.Kind, node.Value)
+}
+
+// convert scalar node (not number) to nil , bool or string.
+// timestamp , binary (not decoded) , and data of tag which can not be converted , is text as written.
+func myScalarNodeToData(node *yaml.Node) (interface{}, error) {
+	if node.ShortTag() == "!!timestamp" || node.ShortTag() == "!!binary" {
+		return node.Value, nil
+	}
+	if node.Tag == "!!bool" && node.Style == 0 {
+		// boolean of yaml 1.1 , like yes , on
+		if b, ok := readAs11(node.Value); ok {
+			if _, ok := b.(bool); ok {
+				return b, nil
+			}
+		}
+	}
+	var result interface{}
+	if err := node.Decode(&result); err != nil {
+		return nil, err
+	}
+	switch result.(type) {
+	case nil, bool, string:
+		return result, nil
+	}
+	return node.Value, nil
+}
+
+// convert json.Number in plain data to go number , int , int64 , uint64 or float64 , like yaml Unmarshal.
+func plainNumbers(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = plainNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = plainNumbers(value)
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			if int64(int(i)) == i {
+				return int(i)
+			}
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return data
+}
+
+// json number format
+var jsonNumberRegexp = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// convert number node to json.Number without loss of precision.
+// integer like 0x1F , 0o755 , 1_000 is converted to decimal.
+func myNumberNodeToData(node *yaml.Node) (interface{}, error) {
+	value := strings.Replace(node.Value, "_", "", -1)
+	if sexagesimalRegexp.MatchString(node.Value) {
+		return sexagesimalNumber(value), nil
+	}
+	if node.Tag == "!!int" {
+		i, ok := new(big.Int).SetString(value, 0)
+		if !ok {
+			return nil, fmt.Errorf("can not convert to integer:%v", node.Value)
+		}
+		return json.Number(i.String()), nil
+	}
+	if jsonNumberRegexp.MatchString(value) {
+		return json.Number(value), nil
+	}
+	// float like .5 , +1.0 , .inf
+	var f float64
+	err := node.Decode(&f)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return f, nil
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+// convert sexagesimal number of yaml 1.1 , like 1:30 , 190:20:30.15 , to json.Number
+func sexagesimalNumber(value string) json.Number {
+	sign := ""
+	if strings.HasPrefix(value, "-") {
+		sign = "-"
+	}
+	parts := strings.Split(strings.TrimLeft(value, "+-"), ":")
+	if strings.Contains(value, ".") {
+		f := 0.0
+		for _, part := range parts {
+			v, _ := strconv.ParseFloat(part, 64)
+			f = f*60 + v
+		}
+		return json.Number(sign + strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	n := new(big.Int)
+	for _, part := range parts {
+		v, _ := new(big.Int).SetString(part, 10)
+		n.Mul(n, big.NewInt(60)).Add(n, v)
+	}
+	return json.Number(sign + n.String())
+}
+
+// merge map (or slice of maps) of merge key (<<) into result. key already exists in result is not overwritten.
+func (c *sorter) myMergeToData(result map[string]interface{}, mergeNode *yaml.Node) error {
+	mergeNode = resolveAlias(mergeNode)
+	var sources []*yaml.Node
+	if mergeNode.Kind == yaml.SequenceNode {
+		sources = mergeNode.Content
+	} else {
+		sources = []*yaml.Node{mergeNode}
+	}
+	for _, src := range sources {
+		value, err := c.myNodeToData(src)
+		if err != nil {
+			return err
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("merge key value is not map:%v", reflect.TypeOf(value))
+		}
+		for k, v := range m {
+			if _, exists := result[k]; !exists {
+				result[k] = v
+			}
+		}
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------
+// load yaml data from file
+//
+func (c *sorter) myLoadFromFile(filename string) (*yaml.Node, error) {
+	// read from file
+	myReadBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.myUnmarshal(myReadBytes)
+}
+
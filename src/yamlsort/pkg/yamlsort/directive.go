@@ -0,0 +1,215 @@
+//
+// deletion directives in override file
+//
+package yamlsort
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// directives in override file
+const (
+	// $patch: delete removes map (key or slice item) , $patch: replace replaces map or slice
+	patchKey = "$patch"
+	// $retainKeys: [ key1 , key2 ] removes other keys of map
+	retainKeysKey = "$retainKeys"
+	// $deleteFromPrimitiveList/key: [ value1 , value2 ] removes values from slice of key
+	deleteFromPrimitiveListPrefix = "$deleteFromPrimitiveList/"
+	// key: !delete removes key , - !delete value removes slice item
+	deleteTag = "!delete"
+
+	patchDelete  = "delete"
+	patchReplace = "replace"
+)
+
+// return true if key is directive key of override file
+func isDirectiveKey(k string) bool {
+	return k == patchKey || k == retainKeysKey || strings.HasPrefix(k, deleteFromPrimitiveListPrefix)
+}
+
+// return value of $patch in map. "" if not found.
+func patchDirective(node *yaml.Node) string {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return ""
+	}
+	v := resolveAlias(findMapValue(node, patchKey))
+	if v == nil || v.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return v.Value
+}
+
+// return true if node is map with $patch: replace , or slice with - $patch: replace item
+func isReplaceNode(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	if node == nil {
+		return false
+	}
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if patchDirective(item) == patchReplace {
+				return true
+			}
+		}
+		return false
+	}
+	return patchDirective(node) == patchReplace
+}
+
+// return copy of map or slice without $patch: replace
+func replaceContent(node *yaml.Node) *yaml.Node {
+	result := *node
+	result.Content = nil
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if patchDirective(item) != patchReplace {
+				result.Content = append(result.Content, item)
+			}
+		}
+		return &result
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != patchKey {
+			result.Content = append(result.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+	return &result
+}
+
+// return true if node is !delete or map with $patch: delete
+func isDeleteNode(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	if node == nil {
+		return false
+	}
+	return node.Tag == deleteTag || patchDirective(node) == patchDelete
+}
+
+// return true if node has directive in it
+func hasDirective(node *yaml.Node) bool {
+	if node == nil || node.Kind == yaml.AliasNode {
+		return false
+	}
+	if node.Tag == deleteTag {
+		return true
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if isDirectiveKey(node.Content[i].Value) {
+				return true
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if hasDirective(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// return copy of delete item without directive , to compare with items of base
+func deleteTarget(node *yaml.Node) *yaml.Node {
+	node = resolveAlias(node)
+	result := *node
+	result.Content = nil
+	for i := 0; i+1 < len(node.Content) && node.Kind == yaml.MappingNode; i += 2 {
+		if !isDirectiveKey(node.Content[i].Value) {
+			result.Content = append(result.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+	if node.Kind == yaml.SequenceNode {
+		result.Content = node.Content
+	}
+	if result.Tag == deleteTag {
+		// tag of value , like !!str , !!int
+		result.Tag = ""
+		result.Tag = result.ShortTag()
+	}
+	return &result
+}
+
+// return override node without directive. directives are applied to empty node.
+func (c *sorter) cleanOverrideNode(path string, node *yaml.Node) (*yaml.Node, error) {
+	if !hasDirective(node) {
+		return node, nil
+	}
+	empty := *node
+	empty.Content = nil
+	empty.Anchor = ""
+	return c.myOverrideRecursive(path, &empty, node)
+}
+
+// remove items of base which match delete items. map item is matched by identity key , or same data.
+func (c *sorter) removeItems(path string, items []*yaml.Node, deletes []*yaml.Node) []*yaml.Node {
+	if len(deletes) == 0 {
+		return items
+	}
+	keys := c.mergeKeysAt(path)
+	result := []*yaml.Node{}
+	for _, item := range items {
+		blnDelete := false
+		for _, d := range deletes {
+			target := deleteTarget(d)
+			identity, blnIdentity := itemIdentity(target, keys)
+			identityItem, ok := itemIdentity(item, keys)
+			if blnIdentity && ok && sameIdentity(identity, identityItem) {
+				blnDelete = true
+			} else if !blnIdentity && c.equalNode(target, item) {
+				blnDelete = true
+			}
+		}
+		if !blnDelete {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// apply $retainKeys and $deleteFromPrimitiveList/key of override map to base map
+func (c *sorter) applyMapDirectives(mdest *yaml.Node, m *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k := m.Content[i].Value
+		v := resolveAlias(m.Content[i+1])
+		if !strings.HasPrefix(k, deleteFromPrimitiveListPrefix) || v.Kind != yaml.SequenceNode {
+			continue
+		}
+		listKey := strings.TrimPrefix(k, deleteFromPrimitiveListPrefix)
+		list := resolveAlias(findMapValue(mdest, listKey))
+		if list == nil || list.Kind != yaml.SequenceNode {
+			continue
+		}
+		result := []*yaml.Node{}
+		for _, item := range list.Content {
+			blnDelete := false
+			for _, value := range v.Content {
+				if c.equalNode(value, item) {
+					blnDelete = true
+				}
+			}
+			if !blnDelete {
+				result = append(result, item)
+			}
+		}
+		list.Content = result
+	}
+
+	retain := resolveAlias(findMapValue(m, retainKeysKey))
+	if retain == nil || retain.Kind != yaml.SequenceNode {
+		return
+	}
+	keys := map[string]bool{}
+	for _, key := range retain.Content {
+		keys[key.Value] = true
+	}
+	result := []*yaml.Node{}
+	for i := 0; i+1 < len(mdest.Content); i += 2 {
+		if isMergeKey(mdest.Content[i]) || keys[mdest.Content[i].Value] {
+			result = append(result, mdest.Content[i], mdest.Content[i+1])
+		}
+	}
+	mdest.Content = result
+}
@@ -0,0 +1,193 @@
+//
+// line width , folding of long string and flow style of short collection
+//
+package yamlsort
+
+import (
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// default line width of flow style , when line width is not set
+const defaultFlowLineWidth = 80
+
+// return line width of flow style
+func (c *sorter) flowLineWidth() int {
+	if c.lineWidth > 0 {
+		return c.lineWidth
+	}
+	return defaultFlowLineWidth
+}
+
+//-------------------------------------------------------------------------
+// fold long plain string at spaces , so that lines fit in line width.
+// column is column where string starts , indentstr is indent of folded lines.
+// ok is false when string is short , quoted , or can not be folded safely.
+//
+func (c *sorter) foldString(text string, column int, indentstr string) (string, bool) {
+	if c.lineWidth <= 0 || len(indentstr) == 0 || column+utf8.RuneCountInString(text) <= c.lineWidth {
+		return "", false
+	}
+	if strings.HasPrefix(text, "'") || strings.HasPrefix(text, "\"") {
+		return "", false
+	}
+	words := strings.Split(text, " ")
+	for _, w := range words {
+		// 2 spaces can not be folded
+		if len(w) == 0 {
+			return "", false
+		}
+	}
+	lines := []string{}
+	line := words[0]
+	width := c.lineWidth - column
+	for _, w := range words[1:] {
+		if utf8.RuneCountInString(line)+1+utf8.RuneCountInString(w) > width {
+			lines = append(lines, line)
+			line = w
+			width = c.lineWidth - len(indentstr)
+			continue
+		}
+		line = line + " " + w
+	}
+	lines = append(lines, line)
+	if len(lines) == 1 {
+		return "", false
+	}
+
+	// check folded string is read as same string
+	var data map[string]interface{}
+	if err := yaml.Unmarshal([]byte("k: "+strings.Join(lines, "\n  ")), &data); err != nil {
+		return "", false
+	}
+	if s, ok := data["k"].(string); !ok || s != text {
+		return "", false
+	}
+	return strings.Join(lines, "\n"+indentstr), true
+}
+
+// return true if node has comment
+func hasComment(node *yaml.Node) bool {
+	return len(node.HeadComment) > 0 || len(node.LineComment) > 0 || len(node.FootComment) > 0
+}
+
+// return text of key or scalar value in flow style. ok is false when it is not written in one line.
+func (c *sorter) flowScalarString(path string, node *yaml.Node) (string, bool) {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.ScalarNode || hasComment(node) || (c.blnKeepAnchor && len(node.Anchor) > 0) {
+		return "", false
+	}
+	if node.Tag == "!!int" || node.Tag == "!!float" || node.Tag == "!!timestamp" || (node.Tag == "!!bool" && node.Style == 0) {
+		return node.Value, true
+	}
+	if tag := tagStr(node); len(tag) > 0 {
+		// tagged value , like !Ref name
+		text, ok := c.flowScalarString(path, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: node.Value, Style: node.Style})
+		return tag + " " + text, ok
+	}
+	data, err := myScalarNodeToData(node)
+	if err != nil {
+		return "", false
+	}
+	switch v := data.(type) {
+	case nil:
+		return "null", true
+	case bool:
+		if v {
+			return "true", true
+		}
+		return "false", true
+	case string:
+		if strings.Contains(v, "\n") {
+			return "", false
+		}
+		style, _ := c.quoteStyleAt(path, node)
+		text := c.escapeString(v, style)
+		// flow indicators in plain string , like "a, b" , "::1" , "?x"
+		if text == v && !isFlowPlainSafe(v) {
+			text = doubleQuoteString(v)
+		}
+		return text, true
+	}
+	return "", false
+}
+
+// return text of map key in flow style
+func (c *sorter) flowKeyString(kn *yaml.Node) string {
+	ks := c.keyString(kn)
+	if ks == kn.Value && kn.ShortTag() == "!!str" && !isFlowPlainSafe(ks) {
+		return doubleQuoteString(ks)
+	}
+	return ks
+}
+
+// return true if plain string is read as same string in flow style , as item of slice , key and value of map.
+func isFlowPlainSafe(s string) bool {
+	checks := map[string]interface{}{
+		"[" + s + "]":    []interface{}{s},
+		"{" + s + ": v}": map[string]interface{}{s: "v"},
+		"{k: " + s + "}": map[string]interface{}{"k": s},
+	}
+	for text, expected := range checks {
+		var data interface{}
+		if err := yaml.Unmarshal([]byte(text), &data); err != nil || !reflect.DeepEqual(data, expected) {
+			return false
+		}
+	}
+	return true
+}
+
+//-------------------------------------------------------------------------
+// return map or slice in flow style , like [a, b] , {cpu: 100m} , when --flow-style is set.
+// map or slice must have only scalar values without comments , and fit in line width from column.
+//
+func (c *sorter) flowString(path string, data *yaml.Node, column int) (string, bool) {
+	if !c.blnFlowStyle || data == nil || data.Kind == yaml.AliasNode || len(data.HeadComment) > 0 {
+		return "", false
+	}
+	items := []string{}
+	switch data.Kind {
+	case yaml.MappingNode:
+		for _, ki := range c.sortedKeyList(path, data) {
+			kn := data.Content[ki]
+			childpath := c.calcPathMap(path, kn.Value)
+			if c.checkSkipKey(childpath) || !c.checkSelectKey(childpath) {
+				continue
+			}
+			if hasComment(kn) || kn.Tag == "!!merge" {
+				return "", false
+			}
+			value, ok := c.flowScalarString(childpath, data.Content[ki+1])
+			if !ok {
+				return "", false
+			}
+			items = append(items, c.flowKeyString(kn)+": "+value)
+		}
+		return c.fitFlowString(anchorPrefix(tagStr(data))+"{"+strings.Join(items, ", ")+"}", column)
+	case yaml.SequenceNode:
+		for i, v := range data.Content {
+			childpath := c.calcPathItem(path, i, v)
+			if c.checkSkipKey(childpath) || !c.checkSelectKey(childpath) {
+				continue
+			}
+			value, ok := c.flowScalarString(childpath, v)
+			if !ok {
+				return "", false
+			}
+			items = append(items, value)
+		}
+		return c.fitFlowString(anchorPrefix(tagStr(data))+"["+strings.Join(items, ", ")+"]", column)
+	}
+	return "", false
+}
+
+// ok is false when text does not fit in line width
+func (c *sorter) fitFlowString(text string, column int) (string, bool) {
+	if column+utf8.RuneCountInString(text) > c.flowLineWidth() {
+		return "", false
+	}
+	return text, true
+}
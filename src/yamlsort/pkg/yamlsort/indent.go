@@ -0,0 +1,81 @@
+//
+// indent width and array indent of output
+//
+package yamlsort
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// return indent of array item "- " from parent key
+func (c *sorter) sequenceOffset() int {
+	if c.blnSequenceIndented {
+		return c.indent
+	}
+	return c.sequenceIndent
+}
+
+// return indent level of map or slice under map key at level
+func (c *sorter) childLevel(level int, v *yaml.Node) int {
+	v = resolveAlias(v)
+	if v != nil && v.Kind == yaml.SequenceNode && len(v.Content) > 0 {
+		return level + c.sequenceOffset()
+	}
+	return level + c.indent
+}
+
+//-------------------------------------------------------------------------
+// detect indent width and array indent of input , for IndentAuto and SequenceIndentAuto.
+// most used indent in input is used. default is kept when input has no nested block.
+//
+func (c *sorter) detectIndent(docs []*yaml.Node) {
+	if !c.blnIndentAuto && !c.blnSequenceAuto {
+		return
+	}
+	indents := map[int]int{}
+	sequenceIndents := map[int]int{}
+	for _, doc := range docs {
+		countIndent(doc, indents, sequenceIndents)
+	}
+	if n, ok := mostUsedIndent(indents); ok && c.blnIndentAuto {
+		c.indent = n
+	}
+	if n, ok := mostUsedIndent(sequenceIndents); ok && c.blnSequenceAuto {
+		c.sequenceIndent = n
+	}
+}
+
+// count indent of block map and block slice under map key. column of slice is column of "- ".
+func countIndent(node *yaml.Node, indents map[int]int, sequenceIndents map[int]int) {
+	if node == nil || node.Kind == yaml.AliasNode {
+		return
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			v := node.Content[i+1]
+			if v.Line > k.Line && v.Style&yaml.FlowStyle == 0 {
+				switch {
+				case v.Kind == yaml.MappingNode && v.Column > k.Column:
+					indents[v.Column-k.Column]++
+				case v.Kind == yaml.SequenceNode && v.Column >= k.Column:
+					sequenceIndents[v.Column-k.Column]++
+				}
+			}
+		}
+	}
+	for _, v := range node.Content {
+		countIndent(v, indents, sequenceIndents)
+	}
+}
+
+// return most used indent. smaller indent wins in same count.
+func mostUsedIndent(counts map[int]int) (int, bool) {
+	result := -1
+	for n, count := range counts {
+		if result < 0 || count > counts[result] || (count == counts[result] && n < result) {
+			result = n
+		}
+	}
+	return result, result >= 0
+}
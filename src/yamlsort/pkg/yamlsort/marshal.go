@@ -0,0 +1,709 @@
+//
+// marshal yaml node with sorting map key
+//
+package yamlsort
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+//---------------------------------------------------------------------
+//  stringMacro class
+// helm chart macro value
+//
+type stringMacro struct {
+	value string
+}
+
+func (c *stringMacro) setString(arg string) {
+}
+func (c *stringMacro) getString() string {
+	return c.value
+}
+
+//-----------------------------------------------------------------------------------
+// my marshal (data to string with sorting map key)
+//
+func (c *sorter) myMarshal(data *yaml.Node) ([]byte, error) {
+	// create buffer
+	writer := new(bytes.Buffer)
+	c.outputAnchors = map[*yaml.Node]bool{}
+	c.blnLastKeepString = false
+	err := c.myMershalRecursive(writer, 0, "", false, data)
+	return writer.Bytes(), err
+}
+
+// return socre of priority key name  , like "name"
+func priorIndex(priorkeys []string, s string) int {
+	for i, v := range priorkeys {
+		if s == v {
+			return i
+		}
+	}
+	return 999999
+}
+
+// convert string to int slice, number is convert to one int.
+func convertStringToUint64Slice(s string) ([]uint64, error) {
+	result := []uint64{}
+	digitBuf := []rune{}
+
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			digitBuf = append(digitBuf, r)
+		} else {
+			if len(digitBuf) > 0 {
+				i, err := strconv.ParseInt(string(digitBuf), 10, 64)
+				if err != nil {
+					return result, err
+				}
+				result = append(result, uint64(i))
+				digitBuf = []rune{}
+			}
+			// string character (rune) is may be 32bit value (unicode 16)
+			result = append(result, uint64(r)+0x1000000000000000)
+		}
+	}
+	if len(digitBuf) > 0 {
+		i, err := strconv.ParseInt(string(digitBuf), 10, 64)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, uint64(i))
+		digitBuf = []rune{}
+	}
+	return result, nil
+}
+
+// compair string1 string2 , consider prior key name , and string-number-string key
+// priorkeys is prior key names of the map , see priorKeysAt
+func compairString(priorkeys []string, s1 string, s2 string) bool {
+	// priority key name check
+	score1 := priorIndex(priorkeys, s1)
+	score2 := priorIndex(priorkeys, s2)
+	if score1 != score2 {
+		return score1 < score2
+	}
+
+	uint64slice1, err1 := convertStringToUint64Slice(s1)
+	uint64slice2, err2 := convertStringToUint64Slice(s2)
+	if err1 != nil || err2 != nil {
+		return s1 < s2
+	}
+
+	// string compair with string-number-string
+	len1 := len(uint64slice1)
+	len2 := len(uint64slice2)
+	for i := 0; i < len1 && i < len2; i++ {
+		if uint64slice1[i] != uint64slice2[i] {
+			return uint64slice1[i] < uint64slice2[i]
+		}
+	}
+	return len1 < len2
+}
+
+// return string for output in quote style. string is quoted when it is not read as same string in plain style.
+func (c *sorter) escapeString(value string, style string) string {
+	// control character (\t , \n , ...) and non printable character are escaped in double quote
+	if needsDoubleQuote(value) || style == QuoteStyleDouble {
+		return doubleQuoteString(value)
+	}
+	if style != QuoteStyleSingle && c.isPlainSafe(value) {
+		return value
+	}
+	// quote ' .  in quote ' ,  ' is ''
+	result := "'" + strings.Replace(value, "'", "''", -1) + "'"
+	return result
+}
+
+// quote string with " , and escape special characters
+func doubleQuoteString(value string) string {
+	buf := new(strings.Builder)
+	buf.WriteString("\"")
+	for _, r := range value {
+		switch {
+		case r == '\\':
+			buf.WriteString("\\\\")
+		case r == '"':
+			buf.WriteString("\\\"")
+		case r == '\t':
+			buf.WriteString("\\t")
+		case r == '\n':
+			buf.WriteString("\\n")
+		case r == '\r':
+			buf.WriteString("\\r")
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(buf, "\\x%02x", r)
+		case r != ' ' && !unicode.IsPrint(r) && r <= 0xffff:
+			fmt.Fprintf(buf, "\\u%04x", r)
+		case r != ' ' && !unicode.IsPrint(r):
+			fmt.Fprintf(buf, "\\U%08x", r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteString("\"")
+	return buf.String()
+}
+
+// return header ( |- , | , |+ , >- , ...) and content lines of block scalar for multi-line string.
+// ok is false when string is not multi-line, or can not be output in block style safely.
+func (c *sorter) blockString(value string, indentstr string) (header string, body string, ok bool) {
+	if !strings.Contains(value, "\n") {
+		return "", "", false
+	}
+	// block scalar is indented at least 2 spaces (for top level string)
+	if len(indentstr) < 2 {
+		indentstr = "  "
+	}
+
+	// chomping indicator from trailing newlines
+	text := strings.TrimRight(value, "\n")
+	chomp := "-"
+	switch len(value) - len(text) {
+	case 0:
+		chomp = "-"
+	case 1:
+		chomp = ""
+	default:
+		chomp = "+"
+	}
+	lines := strings.Split(strings.TrimSuffix(value, "\n"), "\n")
+
+	// check string can be output in block style
+	if len(strings.TrimSpace(text)) == 0 {
+		return "", "", false
+	}
+	blnFirstLine := true
+	for _, line := range lines {
+		// indentation is detected from first non-empty line, so it must not start with space.
+		if blnFirstLine && len(line) > 0 {
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+				return "", "", false
+			}
+			blnFirstLine = false
+		}
+		// space only line is read as empty line
+		if len(line) > 0 && len(strings.TrimLeft(line, " \t")) == 0 {
+			return "", "", false
+		}
+		for _, r := range line {
+			if r == '\t' {
+				continue
+			}
+			if r == '\r' || r == '\u0085' || r == '\u2028' || r == '\u2029' || r == '\ufeff' || !unicode.IsPrint(r) {
+				return "", "", false
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if c.blnFoldedString {
+		// folded style. line break between normal lines is written as empty line, because single line break is folded to space.
+		header = ">" + chomp
+		for i, line := range lines {
+			if len(line) == 0 {
+				fmt.Fprintln(buf)
+			} else {
+				fmt.Fprintf(buf, "%s%s\n", indentstr, line)
+			}
+			if len(line) == 0 || unicode.IsSpace([]rune(line)[0]) {
+				continue
+			}
+			// find next non-empty line
+			for j := i + 1; j < len(lines); j++ {
+				if len(lines[j]) > 0 {
+					if !unicode.IsSpace([]rune(lines[j])[0]) {
+						fmt.Fprintln(buf)
+					}
+					break
+				}
+			}
+		}
+		return header, buf.String(), true
+	}
+
+	// literal style
+	header = "|" + chomp
+	for _, line := range lines {
+		if len(line) == 0 {
+			fmt.Fprintln(buf)
+		} else {
+			fmt.Fprintf(buf, "%s%s\n", indentstr, line)
+		}
+	}
+	return header, buf.String(), true
+}
+
+func (c *sorter) calcPathMap(path string, key string) string {
+	key = escapePathKey(key)
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+func (c *sorter) calcPathSlice(path string, index int) string {
+	if len(path) == 0 {
+		return "[" + strconv.Itoa(index) + "]"
+	}
+	return path + "[" + strconv.Itoa(index) + "]"
+}
+
+func (c *sorter) calcPathSliceMap(path string, identity string) string {
+	return path + "[" + identity + "]"
+}
+
+// return path of slice item. item of map with identity keys (default is name) is [key=value] , else [index]
+func (c *sorter) calcPathItem(path string, index int, item *yaml.Node) string {
+	keys := c.mergeKeysAt(path)
+	if values, ok := itemIdentity(item, keys); ok {
+		// sliceの中は identity key 要素を持つmapの場合、特別なpath [key=value]を生成
+		return c.calcPathSliceMap(path, identityString(keys, values))
+	}
+	return c.calcPathSlice(path, index)
+}
+
+func (c *sorter) checkSkipKey(path string) bool {
+	// key path without escape , like "metadata.annotations.app.kubernetes.io/name" , is also matched
+	plainpath := unescapePath(path)
+	for _, s := range c.skipkeys {
+		if len(s) > 0 {
+			if s == path || s == plainpath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *sorter) checkSelectKey(path string) bool {
+
+	// 指定が一つもない場合は常に選択OK
+	if len(c.selectkeys) == 0 {
+		return true
+	}
+
+	// 指定がある場合は、指定されたパスの下だけOK
+	plainpath := unescapePath(path)
+	for _, s := range c.selectkeys {
+		if len(s) > 0 {
+			// 正解に続く道ならとりあえず許可する。ここで探索を打ち切ると正解にたどり着けないので。
+			if strings.HasPrefix(s, path) || strings.HasPrefix(s, plainpath) {
+				return true
+			}
+			// 正解の下は許可する
+			if strings.HasPrefix(path, s) || strings.HasPrefix(plainpath, s) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// resolve alias node to anchored node
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// return true if node is map or slice
+func isCollectionNode(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	if node == nil {
+		return false
+	}
+	return node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode
+}
+
+// find map value node by key name
+func findMapValue(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// return " # comment" string for line comment. empty comments are skipped.
+func lineCommentStr(comments ...string) string {
+	result := ""
+	for _, s := range comments {
+		if len(s) > 0 {
+			result = result + " " + s
+		}
+	}
+	return result
+}
+
+// write head / foot comment with indent
+func (c *sorter) writeComment(writer io.Writer, indentstr string, comment string) {
+	comment = strings.Trim(comment, "\n")
+	if len(comment) == 0 {
+		return
+	}
+	c.blnLastKeepString = false
+	for _, line := range strings.Split(comment, "\n") {
+		if len(line) == 0 {
+			fmt.Fprintln(writer)
+			continue
+		}
+		fmt.Fprintf(writer, "%s%s\n", indentstr, line)
+	}
+}
+
+// write foot comment. foot comment needs empty line after it, or it becomes head comment of next key.
+func (c *sorter) writeFootComment(writer io.Writer, indentstr string, comment string) {
+	if len(strings.Trim(comment, "\n")) == 0 {
+		return
+	}
+	c.writeComment(writer, indentstr, comment)
+	fmt.Fprintln(writer)
+}
+
+// return anchor string in --keep-anchor mode.
+// first output of anchored data is "&anchor" , and after that, it is output as alias "*anchor" (blnAlias is true).
+// so that anchor is always defined before its alias, even if map key order is changed.
+func (c *sorter) anchorStr(node *yaml.Node) (anchor string, blnAlias bool) {
+	if !c.blnKeepAnchor || node == nil {
+		return "", false
+	}
+	target := resolveAlias(node)
+	if target == nil || len(target.Anchor) == 0 {
+		return "", false
+	}
+	if c.outputAnchors[target] {
+		return "*" + target.Anchor, true
+	}
+	c.outputAnchors[target] = true
+	return "&" + target.Anchor, false
+}
+
+// return "&anchor " , "!tag " before value
+func anchorPrefix(anchor string) string {
+	if len(anchor) == 0 {
+		return ""
+	}
+	return anchor + " "
+}
+
+// tags of yaml core schema , which are resolved from value and not written
+var coreTags = map[string]bool{
+	"!!null": true, "!!bool": true, "!!int": true, "!!float": true, "!!str": true,
+	"!!timestamp": true, "!!merge": true, "!!map": true, "!!seq": true, "!": true,
+}
+
+// return tag which is written before value , like !Ref , !!binary . "" for tag of yaml core schema.
+func tagStr(node *yaml.Node) string {
+	if node == nil || len(node.Tag) == 0 {
+		return ""
+	}
+	tag := node.ShortTag()
+	if coreTags[tag] {
+		return ""
+	}
+	if !strings.HasPrefix(tag, "!") {
+		// global tag , like tag:example.com,2000:app
+		return "!<" + tag + ">"
+	}
+	return tag
+}
+
+// return properties of node , anchor and tag , like "&anchor !Ref"
+func propertiesStr(anchor string, node *yaml.Node) string {
+	tag := tagStr(resolveAlias(node))
+	if len(anchor) > 0 && len(tag) > 0 {
+		return anchor + " " + tag
+	}
+	return anchor + tag
+}
+
+// return index of keys in map node , sorted by key. priorkeys (depend on path) are first.
+func (c *sorter) sortedKeyList(path string, data *yaml.Node) []int {
+	var keylist []int
+	for i := 0; i+1 < len(data.Content); i += 2 {
+		keylist = append(keylist, i)
+	}
+	priorkeys := c.priorKeysAt(path)
+	sort.SliceStable(keylist, func(idx1, idx2 int) bool {
+		return compairString(priorkeys, data.Content[keylist[idx1]].Value, data.Content[keylist[idx2]].Value)
+	})
+	return keylist
+}
+
+func (c *sorter) myMershalRecursive(writer io.Writer, level int, path string, blnParentSlide bool, data *yaml.Node) error {
+	if data == nil {
+		fmt.Fprintln(writer, "null")
+		return nil
+	}
+	if data.Kind == yaml.DocumentNode {
+		// data is document
+		if len(strings.Trim(data.HeadComment, "\n")) > 0 {
+			c.writeComment(writer, "", data.HeadComment)
+			fmt.Fprintln(writer)
+		}
+		var content *yaml.Node
+		if len(data.Content) > 0 {
+			content = data.Content[0]
+			if isCollectionNode(content) {
+				c.writeComment(writer, "", content.HeadComment)
+			}
+			if anchor, _ := c.anchorStr(content); len(propertiesStr(anchor, content)) > 0 {
+				// anchored or tagged document. anchor and tag are written before data.
+				fmt.Fprintln(writer, propertiesStr(anchor, content))
+			}
+		}
+		err := c.myMershalRecursive(writer, level, path, blnParentSlide, content)
+		if err != nil {
+			return err
+		}
+		if len(strings.Trim(data.FootComment, "\n")) > 0 {
+			if !c.blnLastKeepString {
+				fmt.Fprintln(writer)
+			}
+			c.writeComment(writer, "", data.FootComment)
+		}
+		return nil
+	}
+	if data.Kind == yaml.AliasNode {
+		// data is alias. output anchored data.
+		return c.myMershalRecursive(writer, level, path, blnParentSlide, resolveAlias(data))
+	}
+	if data.Kind == yaml.MappingNode {
+		// data is map
+
+		// if map has no key , then output {}
+		if len(data.Content) == 0 {
+			indentstr := c.indentstr(level)
+			if blnParentSlide {
+				indentstr = ""
+			}
+			fmt.Fprintf(writer, "%s%s\n", indentstr, "{}")
+			return nil
+		}
+
+		// get sorted key list
+		keylist := c.sortedKeyList(path, data)
+
+		// when parent element is slice , first key follows "- " of parent.
+		blnFirst := blnParentSlide
+		// recursive call
+		for _, ki := range keylist {
+			kn := data.Content[ki]
+			v := data.Content[ki+1]
+			k := kn.Value
+			ks := c.keyString(kn)
+			indentstr := c.indentstr(level)
+			childpath := c.calcPathMap(path, k)
+			// check skip key
+			if c.checkSkipKey(childpath) == true {
+				continue
+			}
+			// heck select key
+			if c.checkSelectKey(childpath) != true {
+				continue
+			}
+			// head comment of key
+			headComment := kn.HeadComment
+			if len(v.HeadComment) > 0 && !isCollectionNode(v) {
+				headComment = strings.TrimPrefix(headComment+"\n"+v.HeadComment, "\n")
+			}
+			// when parent element is slice and print first key value, no need to indent
+			if blnFirst {
+				blnFirst = false
+				indentstr = ""
+				if len(headComment) > 0 {
+					// comment follows "- " , and key is written in next line.
+					lines := strings.SplitN(headComment, "\n", 2)
+					fmt.Fprintln(writer, lines[0])
+					if len(lines) > 1 {
+						c.writeComment(writer, c.indentstr(level), lines[1])
+					}
+					indentstr = c.indentstr(level)
+				}
+			} else {
+				c.writeComment(writer, indentstr, headComment)
+			}
+			anchor, blnAlias := c.anchorStr(v)
+			props := propertiesStr(anchor, v)
+			if blnAlias {
+				// child is alias of already output data
+				fmt.Fprintf(writer, "%s%s: %s%s\n", indentstr, ks, anchor, lineCommentStr(kn.LineComment, v.LineComment))
+			} else if isCollectionNode(v) {
+				// child is map or slice
+				if flow, ok := c.flowString(childpath, v, level+len(ks)+2); ok && len(anchor) == 0 {
+					// short map or slice in flow style
+					fmt.Fprintf(writer, "%s%s: %s%s\n", indentstr, ks, flow, lineCommentStr(kn.LineComment, v.LineComment))
+					c.writeFootComment(writer, c.indentstr(level), v.FootComment)
+					c.writeFootComment(writer, c.indentstr(level), kn.FootComment)
+					continue
+				}
+				childlevel := c.childLevel(level, v)
+				fmt.Fprintf(writer, "%s%s:%s%s\n", indentstr, ks, lineCommentStr(props), lineCommentStr(kn.LineComment, v.LineComment))
+				c.writeComment(writer, c.indentstr(childlevel), v.HeadComment)
+				err := c.myMershalRecursive(writer, childlevel, childpath, false, v)
+				if err != nil {
+					return err
+				}
+			} else {
+				// child is normal string or null
+				fmt.Fprintf(writer, "%s%s: %s", indentstr, ks, anchorPrefix(props))
+				column := level + len(ks) + 2 + len(anchorPrefix(props))
+				err := c.myMarshalScalar(writer, level+c.indent, column, childpath, resolveAlias(v), kn.LineComment)
+				if err != nil {
+					return err
+				}
+			}
+			c.writeFootComment(writer, c.indentstr(level), v.FootComment)
+			c.writeFootComment(writer, c.indentstr(level), kn.FootComment)
+		}
+		return nil
+	} else if data.Kind == yaml.SequenceNode {
+		// data is slice. level is column of "- ".
+
+		// if array has no data, then output []
+		if len(data.Content) == 0 {
+			indentstr := c.indentstr(level)
+			if blnParentSlide {
+				indentstr = ""
+			}
+			fmt.Fprintf(writer, "%s%s\n", indentstr, "[]")
+			return nil
+		}
+
+		// when parent element is slice , first item follows "- " of parent.
+		blnFirst := blnParentSlide
+		for i, v := range data.Content {
+			childpath := c.calcPathItem(path, i, v)
+			// check skip key
+			if c.checkSkipKey(childpath) == true {
+				continue
+			}
+			// heck select key
+			if c.checkSelectKey(childpath) != true {
+				continue
+			}
+			indentstr := c.indentstr(level)
+			if blnFirst {
+				indentstr = ""
+				if len(v.HeadComment) > 0 {
+					// comment follows "- " , and item is written in next line.
+					lines := strings.SplitN(v.HeadComment, "\n", 2)
+					fmt.Fprintln(writer, lines[0])
+					if len(lines) > 1 {
+						c.writeComment(writer, c.indentstr(level), lines[1])
+					}
+					indentstr = c.indentstr(level)
+				}
+				blnFirst = false
+			} else {
+				c.writeComment(writer, indentstr, v.HeadComment)
+			}
+			fmt.Fprintf(writer, "%s- ", indentstr)
+			// item is written after "- "
+			itemlevel := level + 2
+			anchor, blnAlias := c.anchorStr(v)
+			props := propertiesStr(anchor, v)
+			if blnAlias {
+				// item is alias of already output data
+				fmt.Fprintf(writer, "%s%s\n", anchor, lineCommentStr(v.LineComment))
+			} else if flow, ok := c.flowString(childpath, v, itemlevel); ok && len(anchor) == 0 {
+				// short map or slice in flow style
+				fmt.Fprintf(writer, "%s%s\n", flow, lineCommentStr(v.LineComment))
+			} else if isCollectionNode(v) && len(props) > 0 {
+				// anchored or tagged map or slice. anchor and tag are written after "- " , and data is written in next line.
+				fmt.Fprintf(writer, "%s%s\n", props, lineCommentStr(v.LineComment))
+				err := c.myMershalRecursive(writer, itemlevel, childpath, false, v)
+				if err != nil {
+					return err
+				}
+			} else if isCollectionNode(v) {
+				err := c.myMershalRecursive(writer, itemlevel, childpath, true, v)
+				if err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprint(writer, anchorPrefix(props))
+				err := c.myMarshalScalar(writer, itemlevel, itemlevel+len(anchorPrefix(props)), childpath, resolveAlias(v), "")
+				if err != nil {
+					return err
+				}
+			}
+			c.writeFootComment(writer, c.indentstr(level), v.FootComment)
+		}
+		return nil
+	}
+	return c.myMarshalScalar(writer, level, level, path, data, "")
+}
+
+// write scalar value and line comment
+// level is indent of block scalar (multi-line string) content , and folded line. column is column where value starts.
+// path is key path of value , for quote style.
+func (c *sorter) myMarshalScalar(writer io.Writer, level int, column int, path string, node *yaml.Node, lineComment string) error {
+	if node == nil {
+		fmt.Fprintln(writer, "null"+lineCommentStr(lineComment))
+		return nil
+	}
+	comment := lineCommentStr(lineComment, node.LineComment)
+	c.blnLastKeepString = false
+	if node.Tag == "!!int" || node.Tag == "!!float" || node.Tag == "!!timestamp" || (node.Tag == "!!bool" && node.Style == 0) {
+		// data is number , timestamp or plain boolean. output as written, like 0x1F , 1_000 , 1e+06 , 2001-12-14 , yes
+		fmt.Fprintln(writer, node.Value+comment)
+		return nil
+	}
+	data, err := myScalarNodeToData(node)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		// data is null
+		fmt.Fprintln(writer, "null"+comment)
+	} else if s, ok := data.(stringMacro); ok {
+		// data is stringMacro
+		fmt.Fprintln(writer, s.getString()+comment)
+	} else if s, ok := data.(string); ok {
+		// data is string
+		style, blnKeepQuote := c.quoteStyleAt(path, node)
+		if header, body, ok := c.blockString(s, c.indentstr(level)); ok && !blnKeepQuote {
+			// multi-line string is output in block style
+			fmt.Fprint(writer, header+comment+"\n"+body)
+			c.blnLastKeepString = strings.HasSuffix(header, "+")
+			return nil
+		}
+		text := c.escapeString(s, style)
+		if folded, ok := c.foldString(text, column, c.indentstr(level)); ok {
+			// long plain string is folded in line width
+			text = folded
+		}
+		fmt.Fprintln(writer, text+comment)
+	} else if b, ok := data.(bool); ok {
+		// data is bool
+		fmt.Fprintln(writer, strconv.FormatBool(b)+comment)
+	}
+	return nil
+}
+
+func (c *sorter) indentstr(level int) string {
+	result := ""
+	for i := 0; i < level; i++ {
+		result = result + " "
+	}
+	return result
+}
+
@@ -0,0 +1,100 @@
+//
+// identity key of map items in slice , for override and [key=value] path , and merge strategy of slice
+//
+package yamlsort
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// default identity key of map items in slice
+var defaultMergeKeys = []string{"name"}
+
+//---------------------------------------------------------------------
+//  mergeKeyRule class
+// map items of slice at key path matching pattern are identified by keys.
+//
+type mergeKeyRule struct {
+	pattern pathPattern
+	keys    []string
+}
+
+// return identity keys of map items in slice at key path. first matched rule is used.
+func (c *sorter) mergeKeysAt(keypath string) []string {
+	for _, rule := range c.mergeKeyRules {
+		if rule.pattern.match(keypath) {
+			return rule.keys
+		}
+	}
+	return defaultMergeKeys
+}
+
+// return values of identity keys of slice item. ok is false when item is not map ,
+// or it does not have scalar value of all keys.
+func itemIdentity(item *yaml.Node, keys []string) (values []*yaml.Node, ok bool) {
+	item = resolveAlias(item)
+	if item == nil || item.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	for _, key := range keys {
+		value := resolveAlias(findMapValue(item, key))
+		if value == nil || value.Kind != yaml.ScalarNode || value.Tag == "!!null" {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+// return true if two identities are same. type and value of each key are compared.
+func sameIdentity(values1 []*yaml.Node, values2 []*yaml.Node) bool {
+	if len(values1) != len(values2) {
+		return false
+	}
+	for i := range values1 {
+		if values1[i].Tag != values2[i].Tag || values1[i].Value != values2[i].Value {
+			return false
+		}
+	}
+	return true
+}
+
+// return path segment of identity , like "name=app" or "containerPort=80,protocol=TCP"
+func identityString(keys []string, values []*yaml.Node) string {
+	items := []string{}
+	for i, key := range keys {
+		items = append(items, key+"="+values[i].Value)
+	}
+	return strings.Join(items, ",")
+}
+
+//---------------------------------------------------------------------
+//  listMergeRule class
+// slices at key path matching pattern are merged by strategy.
+//
+type listMergeRule struct {
+	pattern  pathPattern
+	strategy string
+}
+
+// check list merge strategy name
+func checkListMerge(strategy string) error {
+	switch strategy {
+	case ListMergeByKey, ListMergeReplace, ListMergeAppend, ListMergePrepend, ListMergeUnion, ListMergeIndex:
+		return nil
+	}
+	return fmt.Errorf("unknown list merge strategy:%v (%v)", strategy, strings.Join([]string{ListMergeByKey, ListMergeReplace, ListMergeAppend, ListMergePrepend, ListMergeUnion, ListMergeIndex}, " , "))
+}
+
+// return merge strategy of slice at key path. first matched rule is used.
+func (c *sorter) listMergeAt(keypath string) string {
+	for _, rule := range c.listMergeRules {
+		if rule.pattern.match(keypath) {
+			return rule.strategy
+		}
+	}
+	return c.listMerge
+}
@@ -0,0 +1,505 @@
+//
+// override (merge) yaml node
+//
+package yamlsort
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+//-------------------------------------------------------------------------
+// my Override
+//
+
+func (c *sorter) myOverride(data *yaml.Node, dataOverride *yaml.Node) (*yaml.Node, error) {
+	result, err := c.myOverrideRecursive("", data, dataOverride)
+	return result, err
+}
+
+// return true if node is null value
+func isNullNode(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	if node == nil {
+		return true
+	}
+	return node.Kind == 0 || (node.Kind == yaml.ScalarNode && node.Tag == "!!null")
+}
+
+// return true if key node is merge key (<<)
+func isMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!merge"
+}
+
+// return source maps of merge key value. value is map or slice of maps.
+func mergeSources(value *yaml.Node) []*yaml.Node {
+	value = resolveAlias(value)
+	if value == nil {
+		return nil
+	}
+	if value.Kind == yaml.SequenceNode {
+		var result []*yaml.Node
+		for _, v := range value.Content {
+			result = append(result, resolveAlias(v))
+		}
+		return result
+	}
+	return []*yaml.Node{value}
+}
+
+// find map value node by key name , from map merged with merge key (<<).
+func findMergedValue(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !isMergeKey(node.Content[i]) && node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if isMergeKey(node.Content[i]) {
+			for _, src := range mergeSources(node.Content[i+1]) {
+				if v := findMergedValue(src, key); v != nil {
+					return v
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deep copy node. anchor is removed from copy, alias in copy refers to same anchored node.
+func copyNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	result := *node
+	result.Anchor = ""
+	result.Content = nil
+	for _, child := range node.Content {
+		result.Content = append(result.Content, copyNode(child))
+	}
+	return &result
+}
+
+// deep copy node tree with anchor. alias in copy refers to anchored node in copy.
+func cloneNode(node *yaml.Node) *yaml.Node {
+	copies := map[*yaml.Node]*yaml.Node{}
+	result := cloneNodeRecursive(node, copies)
+	for _, v := range copies {
+		if copied, ok := copies[v.Alias]; ok {
+			v.Alias = copied
+		}
+	}
+	return result
+}
+
+func cloneNodeRecursive(node *yaml.Node, copies map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	result := *node
+	result.Content = nil
+	for _, child := range node.Content {
+		result.Content = append(result.Content, cloneNodeRecursive(child, copies))
+	}
+	copies[node] = &result
+	return &result
+}
+
+//-------------------------------------------------------------------------
+// expand merge key (<<) into plain map. explicit key is prior to merged key.
+//
+func (c *sorter) myExpandMergeKey(node *yaml.Node) {
+	c.myExpandMergeKeyRecursive(node, map[*yaml.Node]bool{})
+}
+
+func (c *sorter) myExpandMergeKeyRecursive(node *yaml.Node, done map[*yaml.Node]bool) {
+	node = resolveAlias(node)
+	if node == nil || done[node] {
+		return
+	}
+	done[node] = true
+	// expand children (and source maps of merge key) at first
+	for _, child := range node.Content {
+		c.myExpandMergeKeyRecursive(child, done)
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	var content []*yaml.Node
+	var merges []*yaml.Node
+	exists := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if isMergeKey(node.Content[i]) {
+			merges = append(merges, node.Content[i+1])
+			continue
+		}
+		content = append(content, node.Content[i], node.Content[i+1])
+		exists[node.Content[i].Value] = true
+	}
+	for _, merge := range merges {
+		for _, src := range mergeSources(merge) {
+			if src == nil || src.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 0; i+1 < len(src.Content); i += 2 {
+				k := src.Content[i].Value
+				if exists[k] {
+					continue
+				}
+				exists[k] = true
+				content = append(content, copyNode(src.Content[i]), copyNode(src.Content[i+1]))
+			}
+		}
+	}
+	node.Content = content
+}
+
+// path is key path of data , for identity keys of slice items
+func (c *sorter) myOverrideRecursive(path string, data *yaml.Node, dataOverride *yaml.Node) (*yaml.Node, error) {
+	// document node is override with its content
+	if data != nil && data.Kind == yaml.DocumentNode && len(data.Content) > 0 {
+		if dataOverride != nil && dataOverride.Kind == yaml.DocumentNode {
+			if len(dataOverride.Content) == 0 {
+				return data, nil
+			}
+			dataOverride = dataOverride.Content[0]
+		}
+		result, err := c.myOverrideRecursive(path, data.Content[0], dataOverride)
+		if err != nil {
+			return data, err
+		}
+		data.Content[0] = result
+		return data, nil
+	}
+	if dataOverride != nil && dataOverride.Kind == yaml.DocumentNode {
+		if len(dataOverride.Content) == 0 {
+			return data, nil
+		}
+		dataOverride = dataOverride.Content[0]
+	}
+	if isNullNode(dataOverride) {
+		return data, nil
+	}
+	if isNullNode(data) {
+		return c.cleanOverrideNode(path, dataOverride)
+	}
+	if data.Kind == yaml.AliasNode && isCollectionNode(dataOverride) {
+		// alias is copied before override, so that anchored data and other alias are not changed.
+		data = copyNode(resolveAlias(data))
+	}
+
+	mdest := resolveAlias(data)
+	m := resolveAlias(dataOverride)
+	if isReplaceNode(m) {
+		// $patch: replace in map , or - $patch: replace item in slice. base value is replaced.
+		return c.cleanOverrideNode(path, replaceContent(m))
+	}
+	if mdest.Kind == yaml.MappingNode && m.Kind == yaml.MappingNode {
+		// dataOverride is map
+		// get key list
+		var keylist []int
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			keylist = append(keylist, i)
+		}
+		// sort map key, but key priorkeys is first
+		sort.SliceStable(keylist, func(idx1, idx2 int) bool {
+			return compairString(c.priorkeys, m.Content[keylist[idx1]].Value, m.Content[keylist[idx2]].Value)
+		})
+		// recursive call
+		for _, ki := range keylist {
+			k := m.Content[ki].Value
+			v := m.Content[ki+1]
+			if isDirectiveKey(k) {
+				continue
+			}
+			destIndex := -1
+			for i := 0; i+1 < len(mdest.Content); i += 2 {
+				if mdest.Content[i].Value == k {
+					destIndex = i + 1
+				}
+			}
+			if isDeleteNode(v) {
+				// key: !delete , key: { $patch: delete }
+				if destIndex >= 0 {
+					mdest.Content = append(mdest.Content[:destIndex-1], mdest.Content[destIndex+1:]...)
+				}
+				continue
+			}
+			if destIndex < 0 && isCollectionNode(v) {
+				// key is merged with merge key (<<) , then copy merged value as explicit key and override it.
+				if vmerged := findMergedValue(mdest, k); vmerged != nil {
+					mdest.Content = append(mdest.Content, m.Content[ki], copyNode(resolveAlias(vmerged)))
+					destIndex = len(mdest.Content) - 1
+				}
+			}
+			// vdest is nil, then copy and continue
+			if destIndex < 0 {
+				v, err := c.cleanOverrideNode(c.calcPathMap(path, k), v)
+				if err != nil {
+					return data, err
+				}
+				mdest.Content = append(mdest.Content, m.Content[ki], v)
+				continue
+			}
+			vdest := mdest.Content[destIndex]
+			if isNullNode(vdest) {
+				v, err := c.cleanOverrideNode(c.calcPathMap(path, k), v)
+				if err != nil {
+					return data, err
+				}
+				mdest.Content[destIndex] = v
+				continue
+			}
+			if isNullNode(v) {
+				// value is nil. key only.
+				mdest.Content[destIndex] = v
+				continue
+			} else if !isCollectionNode(v) {
+				// value is normal string/float64/int
+				mdest.Content[destIndex] = v
+				continue
+			}
+			// value is map or slice
+			result, err := c.myOverrideRecursive(c.calcPathMap(path, k), vdest, v)
+			if err != nil {
+				return data, err
+			}
+			mdest.Content[destIndex] = result
+		}
+		c.applyMapDirectives(mdest, m)
+		return data, nil
+	}
+
+	adest := mdest
+	a := m
+	if adest.Kind == yaml.SequenceNode && a.Kind == yaml.SequenceNode {
+		// merge strategy of slice
+		strategy := c.listMergeAt(path)
+		// delete items of base matching delete items (- !delete value , - { name: x , $patch: delete })
+		items := []*yaml.Node{}
+		deletes := []*yaml.Node{}
+		for i, elem := range a.Content {
+			elem = resolveAlias(elem)
+			if isDeleteNode(elem) && strategy != ListMergeIndex {
+				deletes = append(deletes, elem)
+				continue
+			}
+			if strategy != ListMergeByKey && strategy != ListMergeIndex {
+				var err error
+				elem, err = c.cleanOverrideNode(c.calcPathItem(path, i, elem), elem)
+				if err != nil {
+					return data, err
+				}
+			}
+			items = append(items, elem)
+		}
+		if strategy != ListMergeReplace {
+			adest.Content = c.removeItems(path, adest.Content, deletes)
+		}
+		switch strategy {
+		case ListMergeReplace:
+			adest.Content = items
+			return data, nil
+		case ListMergeAppend:
+			adest.Content = append(adest.Content, items...)
+			return data, nil
+		case ListMergePrepend:
+			adest.Content = append(items, adest.Content...)
+			return data, nil
+		case ListMergeUnion:
+			adest.Content = c.dedupeItems(append(adest.Content, items...))
+			return data, nil
+		case ListMergeIndex:
+			err := c.myOverrideItemsByIndex(path, adest, items)
+			return data, err
+		}
+
+		// merge by key
+		// slice check ( slice - map type )
+		blnOverride := false
+
+		// check slice - map[identity key] type. identity key is "name" , or --merge-key of path.
+		keys := c.mergeKeysAt(path)
+		for _, elem := range items {
+			if elem.Kind == yaml.MappingNode {
+				// slice - map
+				identity, blnIdentity := itemIdentity(elem, keys)
+				blnMatched := false
+				for idest, destelem := range adest.Content {
+					// slice - map
+					identitydest, ok := itemIdentity(destelem, keys)
+					if ok && blnIdentity && sameIdentity(identity, identitydest) {
+						result, err := c.myOverrideRecursive(c.calcPathItem(path, idest, destelem), destelem, elem)
+						if err != nil {
+							return data, err
+						}
+						adest.Content[idest] = result
+						blnMatched = true
+					}
+				}
+				blnOverride = true
+				if blnMatched == false {
+					// append
+					elem, err := c.cleanOverrideNode(c.calcPathItem(path, len(adest.Content), elem), elem)
+					if err != nil {
+						return data, err
+					}
+					adest.Content = append(adest.Content, elem)
+				}
+			} else if elem.Kind == yaml.ScalarNode {
+				// check string/int/float64/bool
+				adest.Content = append(adest.Content, elem)
+				blnOverride = true
+			}
+		}
+
+		if blnOverride == false && len(items) > 0 {
+			return data, fmt.Errorf("unknown slice item type:%v  path:%v", items[0].Tag, path)
+		}
+		return data, nil
+	}
+
+	if len(path) == 0 {
+		path = "."
+	}
+	return data, fmt.Errorf("can not override %v: %v with %v", path, mdest.Tag, m.Tag)
+}
+
+// merge item N of override with item N of base. extra items are appended.
+func (c *sorter) myOverrideItemsByIndex(path string, adest *yaml.Node, items []*yaml.Node) error {
+	deleted := map[int]bool{}
+	for i, elem := range items {
+		if isDeleteNode(elem) {
+			// - !delete removes item N
+			deleted[i] = true
+			continue
+		}
+		if i >= len(adest.Content) {
+			elem, err := c.cleanOverrideNode(c.calcPathItem(path, i, elem), elem)
+			if err != nil {
+				return err
+			}
+			adest.Content = append(adest.Content, elem)
+			continue
+		}
+		destelem := adest.Content[i]
+		if isCollectionNode(destelem) && isCollectionNode(elem) && resolveAlias(destelem).Kind == elem.Kind {
+			result, err := c.myOverrideRecursive(c.calcPathItem(path, i, destelem), destelem, elem)
+			if err != nil {
+				return err
+			}
+			adest.Content[i] = result
+			continue
+		}
+		if !isNullNode(elem) || elem == c.setNullNode {
+			// null item keeps base item , except null of --set [index]=null
+			elem, err := c.cleanOverrideNode(c.calcPathItem(path, i, elem), elem)
+			if err != nil {
+				return err
+			}
+			adest.Content[i] = elem
+		}
+	}
+	result := []*yaml.Node{}
+	for i, item := range adest.Content {
+		if !deleted[i] {
+			result = append(result, item)
+		}
+	}
+	adest.Content = result
+	return nil
+}
+
+// load override file. "-" means content of stdin.
+func (c *sorter) myLoadOverride(filename string) (*yaml.Node, error) {
+	if filename == "-" {
+		return c.myUnmarshal(c.overrideStdin)
+	}
+	return c.myLoadFromFile(filename)
+}
+
+// record file name of all nodes in override data
+func markOverrideSource(node *yaml.Node, filename string, sources map[*yaml.Node]string) {
+	if node == nil {
+		return
+	}
+	sources[node] = filename
+	for _, child := range node.Content {
+		markOverrideSource(child, filename, sources)
+	}
+}
+
+//-------------------------------------------------------------------------
+// write key path , value and file name which set it , of each value after override.
+// value without override file name is from input.
+//
+func (c *sorter) writeOverrideReport(data *yaml.Node, sources map[*yaml.Node]string) {
+	base := c.filename
+	if len(base) == 0 {
+		base = "<stdin>"
+	}
+	lines := []string{}
+	c.myOverrideReportRecursive("", data, sources, base, &lines, map[*yaml.Node]bool{})
+	fmt.Fprintf(c.stderr, "--- # override report: %s\n", base)
+	for _, line := range lines {
+		fmt.Fprintln(c.stderr, line)
+	}
+}
+
+func (c *sorter) myOverrideReportRecursive(path string, node *yaml.Node, sources map[*yaml.Node]string, base string, lines *[]string, done map[*yaml.Node]bool) {
+	node = resolveAlias(node)
+	if node == nil || done[node] {
+		return
+	}
+	if node.Kind == yaml.DocumentNode {
+		for _, v := range node.Content {
+			c.myOverrideReportRecursive(path, v, sources, base, lines, done)
+		}
+		return
+	}
+	source, ok := sources[node]
+	if !ok {
+		source = base
+	}
+	if isCollectionNode(node) && len(node.Content) > 0 {
+		done[node] = true
+		if node.Kind == yaml.SequenceNode {
+			for i, v := range node.Content {
+				c.myOverrideReportRecursive(c.calcPathItem(path, i, v), v, sources, base, lines, done)
+			}
+			return
+		}
+		for _, i := range c.sortedKeyList(path, node) {
+			k := node.Content[i]
+			if isMergeKey(k) {
+				// keys merged with merge key (<<) are reported at same path
+				for _, src := range mergeSources(node.Content[i+1]) {
+					c.myOverrideReportRecursive(path, src, sources, base, lines, done)
+				}
+				continue
+			}
+			c.myOverrideReportRecursive(c.calcPathMap(path, k.Value), node.Content[i+1], sources, base, lines, done)
+		}
+		return
+	}
+	value := node.Value
+	switch {
+	case node.Kind == yaml.MappingNode:
+		value = "{}"
+	case node.Kind == yaml.SequenceNode:
+		value = "[]"
+	case node.Tag == "!!str":
+		value = c.escapeString(node.Value, QuoteStyleMinimal)
+	}
+	if len(path) == 0 {
+		// top level
+		path = "."
+	}
+	*lines = append(*lines, fmt.Sprintf("%s = %s  # %s", path, value, source))
+}
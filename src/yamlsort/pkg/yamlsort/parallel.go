@@ -0,0 +1,61 @@
+//
+// worker pool for Options.Jobs
+//
+package yamlsort
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+//-------------------------------------------------------------------------------------
+//  run job for index 0 .. count-1 with c.jobs goroutines.
+//  done is called in index order on caller goroutine , so that output order is same as sequential run.
+//
+func (c *sorter) runParallel(count int, job func(worker *sorter, index int), done func(index int)) {
+	// sequential run
+	if c.jobs <= 1 || count <= 1 {
+		for i := 0; i < count; i++ {
+			job(c, i)
+			done(i)
+		}
+		return
+	}
+
+	workers := make([]*sorter, count)
+	finished := make([]chan struct{}, count)
+	for i := range finished {
+		finished[i] = make(chan struct{})
+	}
+
+	// worker pool
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < c.jobs && n < count; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				workers[i] = c.newWorker()
+				job(workers[i], i)
+				close(finished[i])
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < count; i++ {
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	// write error messages in index order
+	for i := 0; i < count; i++ {
+		<-finished[i]
+		io.Copy(c.stderr, workers[i].stderr.(*bytes.Buffer))
+		done(i)
+		workers[i] = nil
+	}
+	wg.Wait()
+}
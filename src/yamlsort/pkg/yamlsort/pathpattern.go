@@ -0,0 +1,162 @@
+//
+// key path pattern , like "spec.**.containers[*]"
+//
+package yamlsort
+
+import (
+	"path"
+	"strings"
+)
+
+//---------------------------------------------------------------------
+//  pathSegment class
+// one segment of key path. map key , or array item like "[0]" , "[name=app]" , "[*]" (blnItem is true).
+//
+type pathSegment struct {
+	value   string
+	blnItem bool
+}
+
+var pathKeyEscaper = strings.NewReplacer(`\`, `\\`, `.`, `\.`, `[`, `\[`)
+
+// escape "\" , "." and "[" in map key of key path , so that key like "app.kubernetes.io/name" is one segment.
+func escapePathKey(key string) string {
+	return pathKeyEscaper.Replace(key)
+}
+
+// split key path like "spec.containers[name=app].env" into segments "spec" "containers" "[name=app]" "env".
+// "." in [ ] is not separator. map key with "." is written as "a\.b" , or ["a.b"].
+func splitPath(keypath string) []pathSegment {
+	segments := []pathSegment{}
+	buf := new(strings.Builder)
+	depth := 0
+	flush := func(blnItem bool) {
+		if buf.Len() > 0 {
+			segments = append(segments, pathSegment{value: buf.String(), blnItem: blnItem})
+			buf.Reset()
+		}
+	}
+	runes := []rune(keypath)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case ch == '\\' && depth == 0 && i+1 < len(runes):
+			// escaped character in map key , like "\."
+			i++
+			buf.WriteRune(runes[i])
+		case ch == '[' && depth == 0 && i+1 < len(runes) && runes[i+1] == '"':
+			// quoted map key , like ["app.kubernetes.io/name"]
+			flush(false)
+			key, n := quotedPathKey(runes[i+1:])
+			segments = append(segments, pathSegment{value: key})
+			i += n
+		case ch == '[' && depth == 0:
+			flush(false)
+			depth++
+			buf.WriteRune(ch)
+		case ch == '[':
+			depth++
+			buf.WriteRune(ch)
+		case ch == ']' && depth > 0:
+			depth--
+			buf.WriteRune(ch)
+			if depth == 0 {
+				flush(true)
+			}
+		case ch == '.' && depth == 0:
+			flush(false)
+		default:
+			buf.WriteRune(ch)
+		}
+	}
+	flush(depth > 0)
+	return segments
+}
+
+// read quoted map key like "a.b"] after "[" . return key and number of read runes.
+func quotedPathKey(runes []rune) (string, int) {
+	buf := new(strings.Builder)
+	for i := 1; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && i+1 < len(runes):
+			i++
+			buf.WriteRune(runes[i])
+		case runes[i] == '"' && i+1 < len(runes) && runes[i+1] == ']':
+			return buf.String(), i + 2
+		default:
+			buf.WriteRune(runes[i])
+		}
+	}
+	return buf.String(), len(runes)
+}
+
+// remove escape of map keys in key path , like "a\.b" to "a.b"
+func unescapePath(keypath string) string {
+	if !strings.Contains(keypath, `\`) {
+		return keypath
+	}
+	result := ""
+	for _, seg := range splitPath(keypath) {
+		if len(result) > 0 && !seg.blnItem {
+			result = result + "."
+		}
+		result = result + seg.value
+	}
+	return result
+}
+
+//---------------------------------------------------------------------
+//  pathPattern class
+// segments of key path pattern.
+// "*" matches one map key , "[*]" matches one array item , "**" matches any number of segments.
+// segment can have wildcard like "container*" or "[name=web-*]". "." or empty pattern matches top level.
+//
+type pathPattern []pathSegment
+
+func parsePathPattern(pattern string) pathPattern {
+	if pattern == "." {
+		return pathPattern{}
+	}
+	return pathPattern(splitPath(pattern))
+}
+
+// return true if key path matches pattern
+func (p pathPattern) match(keypath string) bool {
+	return matchSegments(p, splitPath(keypath))
+}
+
+func matchSegments(pattern []pathSegment, segments []pathSegment) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == (pathSegment{value: "**"}) {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 || !matchSegment(pattern[0], segments[0]) {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// match one segment. map key and array item do not match each other.
+func matchSegment(pattern pathSegment, segment pathSegment) bool {
+	if pattern.blnItem != segment.blnItem {
+		return false
+	}
+	p := pattern.value
+	v := segment.value
+	if segment.blnItem {
+		p = strings.TrimSuffix(strings.TrimPrefix(p, "["), "]")
+		v = strings.TrimSuffix(strings.TrimPrefix(v, "["), "]")
+	}
+	if p == v || p == "*" {
+		return true
+	}
+	ok, err := path.Match(p, v)
+	return err == nil && ok
+}
@@ -0,0 +1,97 @@
+//
+// built-in key order presets
+//
+package yamlsort
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//---------------------------------------------------------------------
+//  priorKeyRule class
+// prior key names of maps at key path matching pattern.
+//
+type priorKeyRule struct {
+	pattern pathPattern
+	keys    []string
+}
+
+// make rule from "path pattern" and "key1,key2,..."
+func newPriorKeyRule(pattern string, keys string) priorKeyRule {
+	return priorKeyRule{pattern: parsePathPattern(pattern), keys: strings.Split(keys, ",")}
+}
+
+// containers of pod template
+var kubernetesContainerKeys = "name,image,imagePullPolicy,command,args,workingDir,env,envFrom,ports,resources,volumeMounts,livenessProbe,readinessProbe,startupProbe,securityContext"
+
+// rules of presets. first matched rule is used.
+var presetRules = map[string][]priorKeyRule{
+	// kubernetes manifest
+	"kubernetes": {
+		newPriorKeyRule(".", "apiVersion,kind,metadata,spec,data,stringData,binaryData,type,rules,subjects,roleRef,status"),
+		newPriorKeyRule("items[*]", "apiVersion,kind,metadata,spec,data,stringData,binaryData,type,rules,subjects,roleRef,status"),
+		newPriorKeyRule("**.metadata", "name,generateName,namespace,labels,annotations"),
+		newPriorKeyRule("**.template", "metadata,spec"),
+		newPriorKeyRule("**.jobTemplate", "metadata,spec"),
+		newPriorKeyRule("**.containers[*]", kubernetesContainerKeys),
+		newPriorKeyRule("**.initContainers[*]", kubernetesContainerKeys),
+		newPriorKeyRule("**.ephemeralContainers[*]", kubernetesContainerKeys),
+		newPriorKeyRule("**.env[*]", "name,value,valueFrom"),
+		newPriorKeyRule("**.ports[*]", "name,containerPort,port,targetPort,nodePort,protocol"),
+		newPriorKeyRule("**.volumeMounts[*]", "name,mountPath,subPath,readOnly"),
+		newPriorKeyRule("**.volumes[*]", "name"),
+	},
+	// helm Chart.yaml
+	"helm": {
+		newPriorKeyRule(".", "apiVersion,name,version,kubeVersion,description,type,keywords,home,sources,dependencies,maintainers,icon,appVersion,deprecated,annotations"),
+		newPriorKeyRule("dependencies[*]", "name,version,repository,condition,tags,import-values,alias"),
+		newPriorKeyRule("maintainers[*]", "name,email,url"),
+	},
+	// docker-compose.yaml
+	"docker-compose": {
+		newPriorKeyRule(".", "version,name,services,networks,volumes,configs,secrets"),
+		newPriorKeyRule("services.*", "image,build,container_name,command,entrypoint,environment,env_file,ports,volumes,depends_on"),
+		newPriorKeyRule("services.*.build", "context,dockerfile,args"),
+	},
+	// github actions workflow
+	"github-actions": {
+		newPriorKeyRule(".", "name,run-name,on,permissions,env,defaults,concurrency,jobs"),
+		newPriorKeyRule("jobs.*", "name,runs-on,needs,if,permissions,environment,concurrency,outputs,env,defaults,strategy,container,services,timeout-minutes,continue-on-error,steps"),
+		newPriorKeyRule("jobs.*.steps[*]", "name,id,if,uses,with,run,shell,working-directory,env,continue-on-error,timeout-minutes"),
+	},
+}
+
+// PresetNames returns names of built-in key order presets.
+func PresetNames() []string {
+	names := []string{}
+	for name := range presetRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// return rules of presets
+func presetPriorKeyRules(presets []string) ([]priorKeyRule, error) {
+	rules := []priorKeyRule{}
+	for _, preset := range presets {
+		r, ok := presetRules[preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset:%v (presets are %v)", preset, strings.Join(PresetNames(), ", "))
+		}
+		rules = append(rules, r...)
+	}
+	return rules, nil
+}
+
+// return prior keys of map at key path. default is --key prior keys.
+func (c *sorter) priorKeysAt(keypath string) []string {
+	for _, rule := range c.priorKeyRules {
+		if rule.pattern.match(keypath) {
+			return rule.keys
+		}
+	}
+	return c.priorkeys
+}
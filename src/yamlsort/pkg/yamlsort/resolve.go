@@ -0,0 +1,227 @@
+//
+// quoting of string by resolver of yaml version , and quote style
+//
+package yamlsort
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// values of Options.YAMLVersion
+const (
+	// yes/no , on/off , y/n are boolean , 0777 is octal number , 1:30 is sexagesimal number
+	YAMLVersion11 = "1.1"
+	// core schema. only true/false are boolean
+	YAMLVersion12 = "1.2"
+)
+
+// sexagesimal number of yaml 1.1 , like 190:20:30 or 1:30.5
+var sexagesimalRegexp = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+
+// words which may be read as boolean or null
+var resolvedWords = map[string]bool{
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true,
+}
+
+// return true if string is plain word which is not resolved as other type , like "name" , "app-1" , "nginx"
+func isSimplePlain(value string) bool {
+	if len(value) == 0 || resolvedWords[strings.ToLower(value)] {
+		return false
+	}
+	for i, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == '/'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// return value of plain scalar read by yaml 1.1 parser , like true for yes/on . ok is false when it is not read.
+func readAs11(value string) (data interface{}, ok bool) {
+	var m map[string]interface{}
+	if err := yamlv2.Unmarshal([]byte("k: "+value+"\n"), &m); err != nil {
+		return nil, false
+	}
+	data, ok = m["k"]
+	return data, ok
+}
+
+// return tag of plain scalar in yaml 1.1 , for string of yaml 1.2 which is other type in yaml 1.1 ,
+// like yes/on/y (bool) , 1:30 (sexagesimal number). "" if it is string in yaml 1.1 too.
+func resolveTag11(value string) string {
+	if sexagesimalRegexp.MatchString(value) {
+		if strings.Contains(value, ".") {
+			return "!!float"
+		}
+		return "!!int"
+	}
+	data, ok := readAs11(value)
+	if !ok {
+		return ""
+	}
+	switch data.(type) {
+	case bool:
+		return "!!bool"
+	case int, int64, uint64:
+		return "!!int"
+	case float64:
+		return "!!float"
+	}
+	return ""
+}
+
+// resolve plain scalar values of input in yaml version , because yaml.v3 reads yes/on , 1:30 as string (yaml 1.2).
+// map keys are not changed.
+func (c *sorter) resolvePlainScalars(node *yaml.Node) {
+	if node == nil || c.yamlVersion != YAMLVersion11 {
+		return
+	}
+	if node.Kind == yaml.ScalarNode && node.Style == 0 && node.Tag == "!!str" {
+		if tag := resolveTag11(node.Value); len(tag) > 0 {
+			node.Tag = tag
+		}
+	}
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		c.resolvePlainScalars(child)
+	}
+}
+
+// return true if "k: value" is read as same string by unmarshal
+func readAsSameString(value string, unmarshal func([]byte, interface{}) error) bool {
+	var data map[string]interface{}
+	if err := unmarshal([]byte("k: "+value+"\n"), &data); err != nil {
+		return false
+	}
+	s, ok := data["k"].(string)
+	return ok && s == value
+}
+
+// return true if "value: v" is read with same key by unmarshal
+func readAsSameKey(value string) bool {
+	var data map[string]interface{}
+	if err := yaml.Unmarshal([]byte(value+": v\n"), &data); err != nil {
+		return false
+	}
+	_, ok := data[value]
+	return ok && len(data) == 1
+}
+
+// return true if string needs double quote , because it has control character or non printable character
+func needsDoubleQuote(value string) bool {
+	for _, r := range value {
+		if r != ' ' && !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+//-------------------------------------------------------------------------
+// return true if string is read as same string in plain style (without quote) ,
+// by yaml parser of yaml version.
+//
+func (c *sorter) isPlainSafe(value string) bool {
+	if isSimplePlain(value) {
+		return true
+	}
+	if needsDoubleQuote(value) || strings.TrimSpace(value) != value {
+		return false
+	}
+	// document marker , and merge key
+	if strings.HasPrefix(value, "---") || strings.HasPrefix(value, "...") || value == "<<" {
+		return false
+	}
+	// yaml 1.2 (core schema)
+	if !readAsSameString(value, yaml.Unmarshal) {
+		return false
+	}
+	if c.yamlVersion == YAMLVersion12 {
+		return true
+	}
+	// yaml 1.1
+	if sexagesimalRegexp.MatchString(value) {
+		return false
+	}
+	return readAsSameString(value, yamlv2.Unmarshal)
+}
+
+// return map key for output. string key is quoted when it is not read as same key ,
+// or it is quoted in input and read as other type in yaml version.
+func (c *sorter) keyString(kn *yaml.Node) string {
+	k := kn.Value
+	if kn.Tag != "!!str" || isSimplePlain(k) {
+		return k
+	}
+	blnQuoted := kn.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0
+	if needsDoubleQuote(k) {
+		return doubleQuoteString(k)
+	}
+	if !readAsSameKey(k) || !readAsSameString(k, yaml.Unmarshal) || (blnQuoted && !c.isPlainSafe(k)) {
+		return "'" + strings.Replace(k, "'", "''", -1) + "'"
+	}
+	return k
+}
+
+// check yaml version option. "" means 1.1.
+func checkYAMLVersion(version string) (string, error) {
+	switch version {
+	case "":
+		return YAMLVersion11, nil
+	case YAMLVersion11, YAMLVersion12:
+		return version, nil
+	}
+	return "", fmt.Errorf("unknown yaml version:%v (%v or %v)", version, YAMLVersion11, YAMLVersion12)
+}
+
+//---------------------------------------------------------------------
+//  quoteRule class
+// string values at key path matching pattern are quoted in style.
+//
+type quoteRule struct {
+	pattern pathPattern
+	style   string
+}
+
+// check quote style name
+func checkQuoteStyle(style string) error {
+	switch style {
+	case QuoteStyleMinimal, QuoteStyleSingle, QuoteStyleDouble, QuoteStylePreserve:
+		return nil
+	}
+	return fmt.Errorf("unknown quote style:%v (%v , %v , %v or %v)", style, QuoteStyleMinimal, QuoteStyleSingle, QuoteStyleDouble, QuoteStylePreserve)
+}
+
+// return quote style of string value at key path. first matched rule is used.
+// preserve is resolved by quote of node in input , and blnKeepQuote is true when the node is quoted.
+func (c *sorter) quoteStyleAt(path string, node *yaml.Node) (style string, blnKeepQuote bool) {
+	style = c.quoteStyle
+	for _, rule := range c.quoteRules {
+		if rule.pattern.match(path) {
+			style = rule.style
+			break
+		}
+	}
+	if style != QuoteStylePreserve {
+		return style, false
+	}
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		return QuoteStyleDouble, true
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		return QuoteStyleSingle, true
+	}
+	return QuoteStyleMinimal, false
+}
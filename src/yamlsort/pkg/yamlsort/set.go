@@ -0,0 +1,173 @@
+//
+// set value at key path , like --set option
+//
+package yamlsort
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//---------------------------------------------------------------------
+//  setValue class
+// segments of key path , and value node. value node is copied for each document.
+//
+type setValue struct {
+	path     string
+	segments []pathSegment
+	node     *yaml.Node
+}
+
+// split key path of set value into segments. wildcard is not allowed.
+func parseSetPath(keypath string) ([]pathSegment, error) {
+	if len(keypath) == 0 || keypath == "." {
+		return nil, fmt.Errorf("bad set value path:%v (empty path)", keypath)
+	}
+	segments := splitPath(keypath)
+	for _, seg := range segments {
+		if strings.ContainsAny(seg.value, "*?") {
+			return nil, fmt.Errorf("bad set value path:%v (wildcard can not be used)", keypath)
+		}
+		if seg.blnItem {
+			if _, _, _, err := parseSelector(seg.value); err != nil {
+				return nil, fmt.Errorf("bad set value path:%v (%v)", keypath, err)
+			}
+		}
+	}
+	return segments, nil
+}
+
+// parse array item segment "[3]" or "[key=value,key2=value2]". index is -1 for [key=value].
+func parseSelector(seg string) (index int, keys []string, values []string, err error) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(seg, "["), "]")
+	if !strings.Contains(inner, "=") {
+		index, err := strconv.Atoi(inner)
+		if err != nil || index < 0 {
+			return 0, nil, nil, fmt.Errorf("bad index:%v", seg)
+		}
+		return index, nil, nil, nil
+	}
+	for _, item := range strings.Split(inner, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			return 0, nil, nil, fmt.Errorf("bad item selector:%v", seg)
+		}
+		keys = append(keys, kv[0])
+		values = append(values, kv[1])
+	}
+	return -1, keys, values, nil
+}
+
+// return scalar node with resolved tag , like !!int for "3"
+func resolvedScalarNode(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	node.Tag = node.ShortTag()
+	return node
+}
+
+func (c *sorter) newSetValue(set SetValue) (setValue, error) {
+	segments, err := parseSetPath(set.Path)
+	if err != nil {
+		return setValue{}, err
+	}
+	result := setValue{path: set.Path, segments: segments}
+	switch set.Type {
+	case SetTypeAuto:
+		result.node = resolvedScalarNode(set.Value)
+	case SetTypeString:
+		result.node = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: set.Value}
+	case SetTypeJSON:
+		var jsondata interface{}
+		decoder := json.NewDecoder(bytes.NewReader([]byte(set.Value)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&jsondata); err != nil {
+			return setValue{}, fmt.Errorf("bad json value of %v: %v", set.Path, err)
+		}
+		doc, err := c.myDataToNode(jsondata)
+		if err != nil {
+			return setValue{}, err
+		}
+		result.node = doc.Content[0]
+	default:
+		return setValue{}, fmt.Errorf("unknown set value type:%v (string or json)", set.Type)
+	}
+	return result, nil
+}
+
+// return key path pattern of array at segments. array items are [*] , because item path is [key=value] or [index].
+func setRulePattern(segments []pathSegment) pathPattern {
+	pattern := pathPattern{}
+	for _, seg := range segments {
+		if seg.blnItem {
+			seg = pathSegment{value: "[*]", blnItem: true}
+		}
+		pattern = append(pattern, seg)
+	}
+	return pattern
+}
+
+//-------------------------------------------------------------------------
+// set value at key path. override data is made from key path , and merged by myOverride.
+// [key=value] is merged by the keys , [index] is merged by index.
+//
+func (c *sorter) mySetValue(data *yaml.Node, set setValue, sources map[*yaml.Node]string) (*yaml.Node, error) {
+	// map and slice value replaces base value
+	node := copyNode(set.node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		node.Content = append([]*yaml.Node{resolvedScalarNode(patchKey), resolvedScalarNode(patchReplace)}, node.Content...)
+	case yaml.SequenceNode:
+		replace := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{resolvedScalarNode(patchKey), resolvedScalarNode(patchReplace)}}
+		node.Content = append(node.Content, replace)
+	}
+	// rules of arrays in key path are prior to other rules
+	setter := *c
+	if isNullNode(node) {
+		setter.setNullNode = node
+	}
+	setter.mergeKeyRules = append([]mergeKeyRule{}, c.mergeKeyRules...)
+	setter.listMergeRules = append([]listMergeRule{}, c.listMergeRules...)
+	for i := len(set.segments) - 1; i >= 0; i-- {
+		seg := set.segments[i]
+		if !seg.blnItem {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg.value}, node}}
+			continue
+		}
+		pattern := setRulePattern(set.segments[:i])
+		index, keys, values, _ := parseSelector(seg.value)
+		if index >= 0 {
+			// [index] . items before index are null , and they keep items of base.
+			list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for n := 0; n < index; n++ {
+				list.Content = append(list.Content, resolvedScalarNode("null"))
+			}
+			list.Content = append(list.Content, node)
+			node = list
+			setter.listMergeRules = append([]listMergeRule{{pattern: pattern, strategy: ListMergeIndex}}, setter.listMergeRules...)
+			continue
+		}
+		// [key=value] . item map has the keys.
+		if node.Kind != yaml.MappingNode {
+			return data, fmt.Errorf("bad set value path:%v (value of %v must be map)", set.path, seg.value)
+		}
+		item := node
+		for n := len(keys) - 1; n >= 0; n-- {
+			if findMapValue(item, keys[n]) == nil {
+				k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: keys[n]}
+				item.Content = append([]*yaml.Node{k, resolvedScalarNode(values[n])}, item.Content...)
+			}
+		}
+		node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{item}}
+		setter.mergeKeyRules = append([]mergeKeyRule{{pattern: pattern, keys: keys}}, setter.mergeKeyRules...)
+		setter.listMergeRules = append([]listMergeRule{{pattern: pattern, strategy: ListMergeByKey}}, setter.listMergeRules...)
+	}
+	if c.blnOverrideReport {
+		markOverrideSource(node, "--set "+set.path, sources)
+	}
+	return setter.myOverride(data, node)
+}
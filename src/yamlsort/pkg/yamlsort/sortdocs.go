@@ -0,0 +1,152 @@
+//
+// sort documents in stream
+//
+package yamlsort
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// install order of kubernetes resource kind , like helm. custom resources come last.
+var kubernetesKindOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"PriorityClass",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ClusterRole",
+	"ClusterRoleList",
+	"ClusterRoleBinding",
+	"ClusterRoleBindingList",
+	"Role",
+	"RoleList",
+	"RoleBinding",
+	"RoleBindingList",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// key of --sort-documents , which means kind in kubernetes install order
+const kubernetesKindKey = "kubernetes-kind"
+
+// built-in document sort keys
+var documentSortPresets = map[string][]string{
+	"kubernetes": {kubernetesKindKey, "metadata.namespace", "metadata.name"},
+}
+
+// expand document sort keys. "kubernetes" is expanded to built-in keys.
+func expandDocumentSortKeys(keys []string) ([]string, error) {
+	result := []string{}
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if preset, ok := documentSortPresets[key]; ok {
+			result = append(result, preset...)
+			continue
+		}
+		if len(key) == 0 {
+			return nil, fmt.Errorf("empty document sort key")
+		}
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+// return value of key path like "metadata.name" in document. ok is false when document does not have scalar value.
+func documentValue(doc *yaml.Node, key string) (value string, ok bool) {
+	node := doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	// map key with "." is escaped like metadata.labels.app\.kubernetes\.io/name , or metadata.labels["app.kubernetes.io/name"]
+	for _, seg := range splitPath(key) {
+		if seg.blnItem {
+			return "", false
+		}
+		node = resolveAlias(findMapValue(node, seg.value))
+		if node == nil {
+			return "", false
+		}
+	}
+	if node.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	return node.Value, true
+}
+
+// return sort values of document
+func (c *sorter) documentSortValues(doc *yaml.Node) (values []string, ok []bool) {
+	for _, key := range c.documentSortKeys {
+		if key == kubernetesKindKey {
+			// rank of install order , and kind name for unknown kind
+			kind, _ := documentValue(doc, "kind")
+			rank := len(kubernetesKindOrder)
+			for i, k := range kubernetesKindOrder {
+				if k == kind {
+					rank = i
+					break
+				}
+			}
+			values = append(values, fmt.Sprintf("%04d %s", rank, kind))
+			ok = append(ok, true)
+			continue
+		}
+		value, blnFound := documentValue(doc, key)
+		values = append(values, value)
+		ok = append(ok, blnFound)
+	}
+	return values, ok
+}
+
+//-------------------------------------------------------------------------
+// sort documents by --sort-documents keys. header comments move with documents.
+// document without the key comes last. same key documents keep order.
+//
+func (c *sorter) mySortDocuments(docs []*yaml.Node, firstlines []string) {
+	if len(c.documentSortKeys) == 0 || len(docs) <= 1 {
+		return
+	}
+	index := make([]int, len(docs))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(idx1, idx2 int) bool {
+		values1, ok1 := c.documentSortValues(docs[index[idx1]])
+		values2, ok2 := c.documentSortValues(docs[index[idx2]])
+		return c.compairListValues(values1, ok1, values2, ok2)
+	})
+	sortedDocs := make([]*yaml.Node, len(docs))
+	sortedFirstlines := make([]string, len(docs))
+	for i, idx := range index {
+		sortedDocs[i] = docs[idx]
+		sortedFirstlines[i] = firstlines[idx]
+	}
+	copy(docs, sortedDocs)
+	copy(firstlines, sortedFirstlines)
+}
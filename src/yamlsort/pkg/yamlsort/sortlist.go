@@ -0,0 +1,139 @@
+//
+// sort slice items by rules
+//
+package yamlsort
+
+import (
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+//---------------------------------------------------------------------
+//  sortListRule class
+// slice at key path matching pattern is sorted by keys.
+//
+type sortListRule struct {
+	pattern pathPattern
+	keys    []string
+}
+
+// return sort rule of slice at key path. ok is false when slice is not sorted.
+func (c *sorter) sortListRuleAt(keypath string) (rule sortListRule, ok bool) {
+	for _, rule := range c.sortListRules {
+		if rule.pattern.match(keypath) {
+			return rule, true
+		}
+	}
+	return sortListRule{}, false
+}
+
+// return sort key values of slice item. map item has values of keys , scalar item has its value.
+// ok is false when map item does not have the key.
+func sortListValues(item *yaml.Node, keys []string) (values []string, ok []bool) {
+	item = resolveAlias(item)
+	if item.Kind == yaml.ScalarNode {
+		return []string{item.Value}, []bool{true}
+	}
+	for _, key := range keys {
+		value := resolveAlias(findMapValue(item, key))
+		if value == nil || value.Kind != yaml.ScalarNode {
+			values = append(values, "")
+			ok = append(ok, false)
+			continue
+		}
+		values = append(values, value.Value)
+		ok = append(ok, true)
+	}
+	return values, ok
+}
+
+// compair sort key values of slice items. item without the key comes last.
+func (c *sorter) compairListValues(values1 []string, ok1 []bool, values2 []string, ok2 []bool) bool {
+	for i := 0; i < len(values1) && i < len(values2); i++ {
+		if ok1[i] != ok2[i] {
+			return ok1[i]
+		}
+		if values1[i] == values2[i] {
+			continue
+		}
+		if c.blnSortListAlpha {
+			return values1[i] < values2[i]
+		}
+		// natural order , like "item2" < "item10"
+		return compairString(nil, values1[i], values2[i])
+	}
+	return false
+}
+
+// return true if two nodes have same data
+func (c *sorter) equalNode(node1 *yaml.Node, node2 *yaml.Node) bool {
+	data1, err1 := c.myNodeToData(node1)
+	data2, err2 := c.myNodeToData(node2)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return reflect.DeepEqual(data1, data2)
+}
+
+// remove item which has same data as previous item
+func (c *sorter) dedupeItems(items []*yaml.Node) []*yaml.Node {
+	result := []*yaml.Node{}
+	for _, item := range items {
+		blnDuplicate := false
+		for _, kept := range result {
+			if c.equalNode(kept, item) {
+				blnDuplicate = true
+				break
+			}
+		}
+		if !blnDuplicate {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+//-------------------------------------------------------------------------
+// sort slices matching rules. comments move with items.
+//
+func (c *sorter) mySortList(node *yaml.Node) {
+	if len(c.sortListRules) == 0 {
+		return
+	}
+	c.mySortListRecursive("", node, map[*yaml.Node]bool{})
+}
+
+func (c *sorter) mySortListRecursive(path string, node *yaml.Node, done map[*yaml.Node]bool) {
+	// alias refers to anchored node , which is sorted at its own path.
+	if node == nil || node.Kind == yaml.AliasNode || done[node] {
+		return
+	}
+	done[node] = true
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, v := range node.Content {
+			c.mySortListRecursive(path, v, done)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			c.mySortListRecursive(c.calcPathMap(path, node.Content[i].Value), node.Content[i+1], done)
+		}
+	case yaml.SequenceNode:
+		if rule, ok := c.sortListRuleAt(path); ok {
+			items := node.Content
+			sort.SliceStable(items, func(idx1, idx2 int) bool {
+				values1, ok1 := sortListValues(items[idx1], rule.keys)
+				values2, ok2 := sortListValues(items[idx2], rule.keys)
+				return c.compairListValues(values1, ok1, values2, ok2)
+			})
+			if c.blnDedupeList {
+				node.Content = c.dedupeItems(items)
+			}
+		}
+		for i, v := range node.Content {
+			c.mySortListRecursive(c.calcPathItem(path, i, v), v, done)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+//
+// split yaml/json stream into documents, and sort each document
+//
+package yamlsort
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	ghodssyaml "github.com/ghodss/yaml"
+	"gopkg.in/yaml.v3"
+)
+
+//-------------------------------------------------------------------------------------
+//  split stream into documents, and marshal each document.
+//
+func (c *sorter) procStream(outputWriter io.Writer, firstlinestr string, inputbytes []byte) error {
+	docs, firstlines, err := c.splitStream(inputbytes)
+
+	// sort documents. header comment moves with document.
+	c.mySortDocuments(docs, firstlines)
+
+	// keep indent of input
+	c.detectIndent(docs)
+
+	// first document without header comment has header of file name
+	if len(firstlines) > 0 && len(firstlines[0]) == 0 {
+		firstlines[0] = firstlinestr
+	}
+
+	// marshal documents with worker pool. output is written in document order.
+	outputs := make([]bytes.Buffer, len(docs))
+	errs := make([]error, len(docs))
+	job := func(worker *sorter, index int) {
+		errs[index] = worker.procOneFile(&outputs[index], firstlines[index], docs[index])
+	}
+	var procErr error
+	done := func(index int) {
+		if procErr != nil {
+			return
+		}
+		outputWriter.Write(outputs[index].Bytes())
+		procErr = errs[index]
+	}
+	c.runParallel(len(docs), job, done)
+	if procErr != nil {
+		return procErr
+	}
+	return err
+}
+
+//-------------------------------------------------------------------------------------
+//  split stream into documents. return documents and header comment of each document.
+//  documents before read error are returned with the error.
+//
+func (c *sorter) splitStream(inputbytes []byte) ([]*yaml.Node, []string, error) {
+	docs := []*yaml.Node{}
+	firstlines := []string{}
+	if c.blnInputJSON {
+		// json stream. json values are written one after another.
+		decoder := json.NewDecoder(bytes.NewReader(inputbytes))
+		decoder.UseNumber()
+		for {
+			var jsondata interface{}
+			err := decoder.Decode(&jsondata)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return docs, firstlines, err
+			}
+			data, err := c.myDataToNode(jsondata)
+			if err != nil {
+				return docs, firstlines, err
+			}
+			docs = append(docs, data)
+			firstlines = append(firstlines, "")
+		}
+		return docs, firstlines, nil
+	}
+
+	// yaml stream. decoder handles directives, "---" with content/comment, "..." document end.
+	normalized := normalizeYAMLInput(inputbytes)
+	lines := strings.Split(string(normalized), "\n")
+	decoder := yaml.NewDecoder(bytes.NewReader(normalized))
+	for {
+		data := &yaml.Node{}
+		err := decoder.Decode(data)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return docs, firstlines, err
+		}
+		c.resolvePlainScalars(data)
+		if isEmptyDocument(data) {
+			comments := documentComments(lines, data)
+			if len(comments) == 0 {
+				// skip empty document like "---" only
+				continue
+			}
+			// comment only document is output as null document with its comment.
+			// decoder may put the comment into foot comment of previous document.
+			if len(docs) > 0 {
+				removeFootComment(docs[len(docs)-1], comments)
+			}
+			data.FootComment = ""
+			docs = append(docs, data)
+			firstlines = append(firstlines, comments[0]+"  ")
+			data.HeadComment = strings.TrimLeft(strings.Join(comments[1:], "\n"), "\n")
+			continue
+		}
+		// header comment of document is output in header line.
+		firstlinestr := ""
+		if comment := takeLeadingComment(data); len(comment) > 0 {
+			firstlinestr = comment + "  "
+		}
+		docs = append(docs, data)
+		firstlines = append(firstlines, firstlinestr)
+	}
+	return docs, firstlines, nil
+}
+
+// gopkg.in/yaml.v3 accepts only "%YAML 1.1" directive. read "%YAML 1.2" document as 1.1 document.
+var yamlDirectiveRegexp = regexp.MustCompile(`^%YAML[ \t]+1\.[0-9]+`)
+
+// normalize input before yaml decode. CRLF is read as LF, so that comment lines do not split.
+// last line always ends with LF.
+func normalizeYAMLInput(inputbytes []byte) []byte {
+	result := bytes.Replace(inputbytes, []byte("\r\n"), []byte("\n"), -1)
+	if len(result) > 0 && result[len(result)-1] != '\n' {
+		result = append(result, '\n')
+	}
+	// directive is only in header of document , before "---". same text in content is not changed.
+	lines := bytes.Split(result, []byte("\n"))
+	blnHeader := true
+	for i, line := range lines {
+		if blnHeader {
+			if yamlDirectiveRegexp.Match(line) {
+				lines[i] = yamlDirectiveRegexp.ReplaceAll(line, []byte("%YAML 1.1"))
+			} else if len(line) > 0 && line[0] != '%' && line[0] != '#' {
+				// "---" or content of document without "---"
+				blnHeader = false
+			}
+		} else if isDocumentEnd(line) {
+			blnHeader = true
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// return true if line is "..." document end marker
+func isDocumentEnd(line []byte) bool {
+	if !bytes.HasPrefix(line, []byte("...")) {
+		return false
+	}
+	rest := line[3:]
+	return len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// return true if document has no content
+func isEmptyDocument(doc *yaml.Node) bool {
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return true
+	}
+	content := doc.Content[0]
+	return content.Kind == yaml.ScalarNode && content.Tag == "!!null" && content.Value == "" && len(content.Anchor) == 0
+}
+
+// return comment lines of empty document from input lines. empty line between comments is kept.
+func documentComments(lines []string, doc *yaml.Node) []string {
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return nil
+	}
+	// document starts at "---" line , and ends before next document
+	comments := []string{}
+	for i := doc.Line - 1; i >= 0 && i < doc.Content[0].Line-1 && i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if i == doc.Line-1 && strings.HasPrefix(line, "---") {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "---"))
+		}
+		if strings.HasPrefix(line, "#") {
+			comments = append(comments, line)
+		} else if len(line) == 0 && len(comments) > 0 {
+			comments = append(comments, line)
+		}
+	}
+	for len(comments) > 0 && len(comments[len(comments)-1]) == 0 {
+		comments = comments[:len(comments)-1]
+	}
+	return comments
+}
+
+// remove comment lines at end of foot comment of document. foot comment may have first lines of comments only.
+func removeFootComment(doc *yaml.Node, comments []string) {
+	foot := strings.TrimRight(doc.FootComment, "\n")
+	for i := len(comments); i > 0; i-- {
+		block := strings.Join(comments[:i], "\n")
+		if strings.HasSuffix(foot, block) {
+			doc.FootComment = strings.TrimRight(strings.TrimSuffix(foot, block), "\n")
+			return
+		}
+	}
+}
+
+// remove first comment line of document, and return it.
+// other lines of leading comment block stay at top of document , even if first key is moved by sort.
+func takeLeadingComment(doc *yaml.Node) string {
+	var target *yaml.Node
+	if len(doc.HeadComment) > 0 {
+		target = doc
+	} else if len(doc.Content) > 0 {
+		content := doc.Content[0]
+		if len(content.HeadComment) > 0 {
+			target = content
+		} else if (content.Kind == yaml.MappingNode || content.Kind == yaml.SequenceNode) && len(content.Content) > 0 {
+			target = content.Content[0]
+		}
+	}
+	if target == nil || len(target.HeadComment) == 0 {
+		return ""
+	}
+	lines := strings.SplitN(target.HeadComment, "\n", 2)
+	target.HeadComment = ""
+	if len(lines) > 1 {
+		rest := strings.TrimLeft(lines[1], "\n")
+		if target == doc || strings.HasSuffix(rest, "\n") {
+			// comment followed by empty line is written as document comment
+			doc.HeadComment = strings.TrimRight(rest, "\n")
+		} else {
+			// comment of first key or item is written before content
+			doc.Content[0].HeadComment = rest
+		}
+	}
+	return lines[0]
+}
+
+//-------------------------------------------------------------------------------------
+//  sort and marshal one document.
+//
+func (c *sorter) procOneFile(outputWriter io.Writer, firstlinestr string, data *yaml.Node) error {
+	// expand merge key
+	if c.blnExpandMergeKey {
+		c.myExpandMergeKey(data)
+	}
+
+	// override files , in order
+	sources := map[*yaml.Node]string{}
+	for _, filename := range c.overridefilenames {
+		dataOverride, err := c.myLoadOverride(filename)
+		if err != nil {
+			return err
+		}
+		if c.blnExpandMergeKey {
+			c.myExpandMergeKey(dataOverride)
+		}
+		if c.blnOverrideReport {
+			name := filename
+			if name == "-" {
+				name = "<stdin>"
+			}
+			markOverrideSource(dataOverride, name, sources)
+		}
+		result, err2 := c.myOverride(data, dataOverride)
+		if err2 != nil {
+			return err2
+		}
+		data = result
+	}
+	// set values
+	for _, set := range c.setValues {
+		result, err := c.mySetValue(data, set, sources)
+		if err != nil {
+			return err
+		}
+		data = result
+	}
+	if c.blnOverrideReport && (len(c.overridefilenames) > 0 || len(c.setValues) > 0) {
+		c.writeOverrideReport(data, sources)
+	}
+
+	// sort slices
+	c.mySortList(data)
+
+	// if firstline contains '# powered by ' , remove it.
+	idx := strings.Index(firstlinestr, "# powered by ")
+	if idx >= 0 {
+		firstlinestr = string([]rune(firstlinestr)[:idx])
+	}
+	if c.blnNormalMarshal {
+		// write yaml data with normal marshal (github.com/ghodss/yaml)
+		plainData, err := c.myNodeToData(data)
+		if err != nil {
+			return err
+		}
+		outputBytes, err := ghodssyaml.Marshal(plainData)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(outputWriter, "---")
+		fmt.Fprintf(outputWriter, "%s%s\n", firstlinestr, "# powered by github.com/ghodss/yaml/Marshal")
+		fmt.Fprintln(outputWriter, string(outputBytes))
+	} else if c.blnJSONMarshal {
+		// write json data with normal marshal
+		plainData, err := c.myNodeToData(data)
+		if err != nil {
+			return err
+		}
+		outputBytes, err := json.MarshalIndent(plainData, "", "  ")
+		if err != nil {
+			return err
+		}
+		// fmt.Fprintln(outputWriter, "---")
+		// fmt.Fprintf(outputWriter, "%s%s\n", firstlinestr, "# powered by json.MarshalIndent output")
+		fmt.Fprintln(outputWriter, string(outputBytes))
+
+	} else {
+		// write yamlsort my marshal
+		outputBytes2, err := c.myMarshal(data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(outputWriter, "---")
+		fmt.Fprintf(outputWriter, "%s%s\n", firstlinestr, "# powered by myMarshal output")
+		if c.blnLastKeepString {
+			// empty line after |+ block scalar is read as its content
+			fmt.Fprint(outputWriter, string(outputBytes2))
+		} else {
+			fmt.Fprintln(outputWriter, string(outputBytes2))
+		}
+	}
+
+	return nil
+}
+
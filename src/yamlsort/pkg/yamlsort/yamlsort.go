@@ -0,0 +1,611 @@
+// Package yamlsort sorts map keys of yaml/json data , and marshals it as yaml text.
+// comments , anchors and number format are kept.
+//
+// API stability:
+// the module is in src/yamlsort of the repository , so its versions are tags like src/yamlsort/v0.2.0 (semantic versioning).
+// tags like 0.2.0 are versions of yamlsort command , and go get does not use them.
+// within the same major version , exported identifiers are not removed or changed incompatibly.
+// new fields may be added to Options and MergeOptions. their zero value keeps previous behavior,
+// so use keyed fields in composite literals, like Options{PriorKeys: []string{"name"}}.
+// output text of Sort and Marshal may change in minor version when it fixes output , or becomes more faithful to input.
+package yamlsort
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//---------------------------------------------------------------------
+//  MergeOptions class
+// option of Merge (and override in Sort)
+type MergeOptions struct {
+	// expand merge key (<<) into plain map before merge
+	ExpandMergeKey bool
+	// identity keys of map items in slices at key path. default identity key is "name".
+	// they are also used in [key=value] of key path.
+	MergeKeys []MergeKeyRule
+	// merge strategy of slices. "" means ListMergeByKey.
+	ListMerge string
+	// merge strategy of slices at key path. prior to ListMerge.
+	ListMergeRules []ListMergeRule
+}
+
+// values of MergeOptions.ListMerge and ListMergeRule.Strategy
+const (
+	// map items with same identity key are merged. other items are appended
+	ListMergeByKey = "merge-by-key"
+	// slice is replaced with override
+	ListMergeReplace = "replace"
+	// items of override are appended
+	ListMergeAppend = "append"
+	// items of override are inserted before items of base
+	ListMergePrepend = "prepend"
+	// items of override are appended , and duplicate items are removed
+	ListMergeUnion = "union"
+	// item N of override is merged with item N of base
+	ListMergeIndex = "index"
+)
+
+//---------------------------------------------------------------------
+//  ListMergeRule class
+// slices at key path matching Path are merged by Strategy. Path is pattern like PriorKeyRule.Path.
+//
+type ListMergeRule struct {
+	Path     string
+	Strategy string
+}
+
+// ParseListMergeRule parses "path=strategy" , like --list-merge-at option.
+func ParseListMergeRule(s string) (ListMergeRule, error) {
+	idx := strings.LastIndex(s, "=")
+	if idx < 0 || strings.Contains(s[idx:], "]") {
+		return ListMergeRule{}, fmt.Errorf("bad list merge rule:%v (format is path=strategy)", s)
+	}
+	rule := ListMergeRule{Path: s[:idx], Strategy: s[idx+1:]}
+	if err := checkListMerge(rule.Strategy); err != nil {
+		return ListMergeRule{}, err
+	}
+	return rule, nil
+}
+
+//---------------------------------------------------------------------
+//  MergeKeyRule class
+// map items of slice at key path matching Path are identified by values of Keys.
+// items with same values of all Keys are merged. Path is pattern like PriorKeyRule.Path.
+//
+type MergeKeyRule struct {
+	Path string
+	Keys []string
+}
+
+// ParseMergeKeyRule parses "path=key" or "path=key1+key2" (composite key) , like --merge-key option.
+func ParseMergeKeyRule(s string) (MergeKeyRule, error) {
+	idx := strings.LastIndex(s, "=")
+	if idx < 0 || strings.Contains(s[idx:], "]") || len(s[idx+1:]) == 0 {
+		return MergeKeyRule{}, fmt.Errorf("bad merge key rule:%v (format is path=key or path=key1+key2)", s)
+	}
+	keys := strings.Split(s[idx+1:], "+")
+	for _, key := range keys {
+		if len(key) == 0 {
+			return MergeKeyRule{}, fmt.Errorf("bad merge key rule:%v (format is path=key or path=key1+key2)", s)
+		}
+	}
+	return MergeKeyRule{Path: s[:idx], Keys: keys}, nil
+}
+
+// values of SetValue.Type
+const (
+	// value is read as yaml scalar , like true , 3 , null
+	SetTypeAuto = ""
+	// value is string
+	SetTypeString = "string"
+	// value is json , like {"cpu":"100m"} or [1,2]
+	SetTypeJSON = "json"
+)
+
+//---------------------------------------------------------------------
+//  SetValue class
+// Value is set at key path Path , like --set option. Path is key path like --skip-key ,
+// with [key=value] and [index] of array item. missing maps and arrays are created.
+// map and array value replaces base value.
+//
+type SetValue struct {
+	Path  string
+	Value string
+	Type  string
+}
+
+// ParseSetValue parses "path=value" , like --set option. "=" in [ ] is not separator.
+func ParseSetValue(s string, setType string) (SetValue, error) {
+	depth := 0
+	for i, ch := range s {
+		switch {
+		case ch == '[':
+			depth++
+		case ch == ']' && depth > 0:
+			depth--
+		case ch == '=' && depth == 0:
+			set := SetValue{Path: s[:i], Value: s[i+1:], Type: setType}
+			if _, err := parseSetPath(set.Path); err != nil {
+				return SetValue{}, err
+			}
+			return set, nil
+		}
+	}
+	return SetValue{}, fmt.Errorf("bad set value:%v (format is path=value)", s)
+}
+
+//---------------------------------------------------------------------
+//  PriorKeyRule class
+// prior key names of maps at key path matching Path.
+// Path is pattern like "metadata" , "spec.template.spec.containers[*]" , "spec.**.env[*]" , "." (top level).
+// "*" matches one map key , "[*]" matches one array item , "**" matches any number of keys and items.
+//
+type PriorKeyRule struct {
+	Path string
+	Keys []string
+}
+
+// ParsePriorKeyRule parses "path=key1,key2" , like --key-at option.
+func ParsePriorKeyRule(s string) (PriorKeyRule, error) {
+	// path may have "=" in [name=value]
+	idx := strings.LastIndex(s, "=")
+	if idx < 0 || strings.Contains(s[idx:], "]") {
+		return PriorKeyRule{}, fmt.Errorf("bad prior key rule:%v (format is path=key1,key2)", s)
+	}
+	return PriorKeyRule{Path: s[:idx], Keys: strings.Split(s[idx+1:], ",")}, nil
+}
+
+//---------------------------------------------------------------------
+//  SortListRule class
+// slice at key path matching Path is sorted. Path is pattern like PriorKeyRule.Path.
+// map items are sorted by values of Keys , and scalar items are sorted by value.
+//
+type SortListRule struct {
+	Path string
+	Keys []string
+}
+
+// ParseSortListRule parses "path=key1,key2" or "path" , like --sort-list option.
+func ParseSortListRule(s string) (SortListRule, error) {
+	idx := strings.LastIndex(s, "=")
+	if idx < 0 || strings.Contains(s[idx:], "]") {
+		// scalar list
+		return SortListRule{Path: s}, nil
+	}
+	if len(s[idx+1:]) == 0 {
+		return SortListRule{}, fmt.Errorf("bad sort list rule:%v (format is path=key1,key2 or path)", s)
+	}
+	return SortListRule{Path: s[:idx], Keys: strings.Split(s[idx+1:], ",")}, nil
+}
+
+// IndentAuto is value of Options.Indent , which keeps indent width of input.
+const IndentAuto = -1
+
+// values of Options.SequenceIndent
+const (
+	// array item "- " is indented from parent key
+	SequenceIndentIndented = "indented"
+	// array item "- " is at same column as parent key
+	SequenceIndentNonIndented = "non-indented"
+	// keep array indent of input
+	SequenceIndentAuto = "auto"
+)
+
+// ParseIndent parses indent width like "2" , "4" or "auto" , like --indent option.
+func ParseIndent(s string) (int, error) {
+	if s == "auto" {
+		return IndentAuto, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("bad indent:%v (indent is number or auto)", s)
+	}
+	return n, nil
+}
+
+// values of Options.QuoteStyle and QuoteRule.Style
+const (
+	// quote string only when it is not read as same string in plain style
+	QuoteStyleMinimal = "minimal"
+	// quote string with '
+	QuoteStyleSingle = "single"
+	// quote string with "
+	QuoteStyleDouble = "double"
+	// keep quote of input. plain string in input is minimal.
+	QuoteStylePreserve = "preserve"
+)
+
+//---------------------------------------------------------------------
+//  QuoteRule class
+// string values at key path matching Path are quoted in Style. Path is pattern like PriorKeyRule.Path.
+//
+type QuoteRule struct {
+	Path  string
+	Style string
+}
+
+// ParseQuoteRule parses "path=style" , like --quote-at option.
+func ParseQuoteRule(s string) (QuoteRule, error) {
+	idx := strings.LastIndex(s, "=")
+	if idx < 0 || strings.Contains(s[idx:], "]") {
+		return QuoteRule{}, fmt.Errorf("bad quote rule:%v (format is path=style)", s)
+	}
+	rule := QuoteRule{Path: s[:idx], Style: s[idx+1:]}
+	if err := checkQuoteStyle(rule.Style); err != nil {
+		return QuoteRule{}, err
+	}
+	return rule, nil
+}
+
+//---------------------------------------------------------------------
+//  Options class
+// option of Sort and Marshal. zero value is default option of yamlsort command.
+type Options struct {
+	MergeOptions
+
+	// prior key name in sort. default is "name".
+	PriorKeys []string
+	// prior key names of maps at key path. prior to Presets.
+	PriorKeyRules []PriorKeyRule
+	// built-in key order presets , like "kubernetes". see PresetNames.
+	Presets []string
+	// sort slices at key path. other slices keep order.
+	SortListRules []SortListRule
+	// sort slice items in alphabetical order , instead of natural order ("item2" < "item10")
+	SortListAlphabetical bool
+	// remove duplicate items in slices sorted by SortListRules
+	DedupeList bool
+	// sort documents in Sort by key paths , like "kind" , "metadata.name".
+	// "kubernetes-kind" is kind in kubernetes install order. "kubernetes" means kubernetes-kind , metadata.namespace , metadata.name
+	SortDocuments []string
+	// skip key path in output , like "spec.template.spec.containers[name=app].env"
+	SkipKeys []string
+	// select key path in output
+	SelectKeys []string
+	// read JSON data in Sort
+	InputJSON bool
+	// use marshal of github.com/ghodss/yaml in Sort
+	NormalMarshal bool
+	// use json marshal (encoding/json) in Sort
+	JSONMarshal bool
+	// string value is always quoted. same as QuoteStyleSingle
+	QuoteString bool
+	// quote style of string value , QuoteStyleMinimal , QuoteStyleSingle , QuoteStyleDouble or QuoteStylePreserve.
+	// "" means minimal (single with QuoteString).
+	QuoteStyle string
+	// quote style of string values at key path. prior to QuoteStyle.
+	QuoteRules []QuoteRule
+	// yaml version of output , YAMLVersion11 or YAMLVersion12. "" means 1.1.
+	// string which is read as other type (like yes , 0777 in 1.1) by the version is quoted.
+	YAMLVersion string
+	// indent width of map in output. 0 means 2. IndentAuto keeps indent width of input in Sort.
+	Indent int
+	// array indent in output. SequenceIndentIndented , SequenceIndentNonIndented or SequenceIndentAuto.
+	// "" means non-indented , or auto with IndentAuto.
+	SequenceIndent string
+	// output array indent + 2. same as SequenceIndentIndented
+	ArrayIndentPlus2 bool
+	// line width of output. long plain string is folded at spaces. 0 means no folding.
+	LineWidth int
+	// output map and slice which have only scalar values in flow style ([a, b] , {k: v}) ,
+	// when it fits in LineWidth (80 if LineWidth is 0)
+	FlowStyle bool
+	// keep anchor (&name) and alias (*name)
+	KeepAnchor bool
+	// output multi-line string in folded style (>) instead of literal style (|)
+	FoldedString bool
+	// paths to override files. they are merged into each document in Sort in order.
+	// "-" means OverrideStdin.
+	OverrideFiles []string
+	// content of override file "-"
+	OverrideStdin []byte
+	// write key path , value and file name which set it , of each value after override , into ErrorWriter
+	OverrideReport bool
+	// values set at key path in Sort , after override files. like --set option.
+	SetValues []SetValue
+	// name written in header line of first document in Sort , like input file name
+	FileName string
+	// number of documents processed in parallel in Sort. 0 or 1 means sequential.
+	Jobs int
+	// report of OverrideReport is written here. nil means no report.
+	ErrorWriter io.Writer
+}
+
+//---------------------------------------------------------------------
+//  Sorter class
+// Sorter has options , and it is safe for concurrent use.
+type Sorter struct {
+	options Options
+}
+
+// New returns Sorter with options.
+func New(options Options) *Sorter {
+	return &Sorter{options: options}
+}
+
+// Sort reads yaml (or json) stream from reader, and writes sorted yaml stream into writer.
+func (s *Sorter) Sort(reader io.Reader, writer io.Writer) error {
+	inputbytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	c, err := newSorter(s.options)
+	if err != nil {
+		return err
+	}
+	firstlinestr := ""
+	if len(s.options.FileName) > 0 {
+		firstlinestr = "# " + s.options.FileName + "  "
+	}
+	return c.procStream(writer, firstlinestr, inputbytes)
+}
+
+// Marshal returns sorted yaml text of v , without document header.
+// v is *yaml.Node , or plain data like map[string]interface{} , []interface{} , string , json.Number .
+// *yaml.Node is not changed.
+func (s *Sorter) Marshal(v interface{}) ([]byte, error) {
+	c, err := newSorter(s.options)
+	if err != nil {
+		return nil, err
+	}
+	node, err := c.toNode(v)
+	if err != nil {
+		return nil, err
+	}
+	node = cloneNode(node)
+	c.detectIndent([]*yaml.Node{node})
+	c.mySortList(node)
+	return c.myMarshal(node)
+}
+
+// Merge merges override into base , like --override-file of yamlsort command.
+// map is merged by key , array item (map) is merged by "name" key , or keys of MergeKeys.
+// base and override are *yaml.Node or plain data. base and override are not changed.
+// result is *yaml.Node if base is *yaml.Node , else plain data.
+func (s *Sorter) Merge(base interface{}, override interface{}) (interface{}, error) {
+	c, err := newSorter(s.options)
+	if err != nil {
+		return nil, err
+	}
+	baseNode, err := c.toNode(base)
+	if err != nil {
+		return nil, err
+	}
+	baseNode = cloneNode(baseNode)
+	overrideNode, err := c.toNode(override)
+	if err != nil {
+		return nil, err
+	}
+	overrideNode = cloneNode(overrideNode)
+	if c.blnExpandMergeKey {
+		c.myExpandMergeKey(baseNode)
+		c.myExpandMergeKey(overrideNode)
+	}
+	result, err := c.myOverride(baseNode, overrideNode)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := base.(*yaml.Node); ok {
+		return result, nil
+	}
+	data, err := c.myNodeToData(result)
+	if err != nil {
+		return nil, err
+	}
+	return plainNumbers(data), nil
+}
+
+// Sort reads yaml (or json) stream from reader, and writes sorted yaml stream into writer.
+func Sort(reader io.Reader, writer io.Writer, options Options) error {
+	return New(options).Sort(reader, writer)
+}
+
+// Marshal returns sorted yaml text of v , without document header.
+func Marshal(v interface{}, options Options) ([]byte, error) {
+	return New(options).Marshal(v)
+}
+
+// Merge merges override into base , and returns merged data.
+func Merge(base interface{}, override interface{}, options MergeOptions) (interface{}, error) {
+	return New(Options{MergeOptions: options}).Merge(base, override)
+}
+
+//---------------------------------------------------------------------
+//  sorter class
+// sorter has options and marshal state of one Sort , Marshal or Merge call.
+type sorter struct {
+	stderr              io.Writer
+	overridefilenames   []string
+	overrideStdin       []byte
+	blnOverrideReport   bool
+	filename            string
+	priorkeys           []string
+	priorKeyRules       []priorKeyRule
+	skipkeys            []string
+	selectkeys          []string
+	blnInputJSON        bool
+	blnNormalMarshal    bool
+	blnJSONMarshal      bool
+	quoteStyle          string
+	quoteRules          []quoteRule
+	yamlVersion         string
+	indent              int
+	blnIndentAuto       bool
+	sequenceIndent      int
+	blnSequenceIndented bool
+	blnSequenceAuto     bool
+	lineWidth           int
+	blnFlowStyle        bool
+	blnKeepAnchor       bool
+	blnFoldedString     bool
+	blnExpandMergeKey   bool
+	sortListRules       []sortListRule
+	mergeKeyRules       []mergeKeyRule
+	listMerge           string
+	listMergeRules      []listMergeRule
+	setValues           []setValue
+	setNullNode         *yaml.Node
+	blnSortListAlpha    bool
+	blnDedupeList       bool
+	documentSortKeys    []string
+	jobs                int
+	outputAnchors       map[*yaml.Node]bool
+	blnLastKeepString   bool
+}
+
+func newSorter(options Options) (*sorter, error) {
+	c := &sorter{
+		stderr:            options.ErrorWriter,
+		overrideStdin:     options.OverrideStdin,
+		blnOverrideReport: options.OverrideReport,
+		filename:          options.FileName,
+		priorkeys:         options.PriorKeys,
+		skipkeys:          options.SkipKeys,
+		selectkeys:        options.SelectKeys,
+		blnInputJSON:      options.InputJSON,
+		blnNormalMarshal:  options.NormalMarshal,
+		blnJSONMarshal:    options.JSONMarshal,
+		quoteStyle:        options.QuoteStyle,
+		blnKeepAnchor:     options.KeepAnchor,
+		lineWidth:         options.LineWidth,
+		blnFlowStyle:      options.FlowStyle,
+		blnFoldedString:   options.FoldedString,
+		blnExpandMergeKey: options.ExpandMergeKey,
+		blnSortListAlpha:  options.SortListAlphabetical,
+		blnDedupeList:     options.DedupeList,
+		jobs:              options.Jobs,
+	}
+	if c.stderr == nil {
+		c.stderr = ioutil.Discard
+	}
+	// check prior keys
+	if len(c.priorkeys) == 0 {
+		c.priorkeys = []string{"name"}
+	}
+	// path dependent prior keys. rules of options are prior to presets.
+	for _, rule := range options.PriorKeyRules {
+		c.priorKeyRules = append(c.priorKeyRules, priorKeyRule{pattern: parsePathPattern(rule.Path), keys: rule.Keys})
+	}
+	rules, err := presetPriorKeyRules(options.Presets)
+	if err != nil {
+		return nil, err
+	}
+	c.priorKeyRules = append(c.priorKeyRules, rules...)
+	for _, rule := range options.MergeKeys {
+		c.mergeKeyRules = append(c.mergeKeyRules, mergeKeyRule{pattern: parsePathPattern(rule.Path), keys: rule.Keys})
+	}
+	// override files
+	c.overridefilenames = options.OverrideFiles
+
+	// set values
+	for _, set := range options.SetValues {
+		v, err := c.newSetValue(set)
+		if err != nil {
+			return nil, err
+		}
+		c.setValues = append(c.setValues, v)
+	}
+
+	// list merge strategy
+	c.listMerge = options.ListMerge
+	if len(c.listMerge) == 0 {
+		c.listMerge = ListMergeByKey
+	}
+	if err := checkListMerge(c.listMerge); err != nil {
+		return nil, err
+	}
+	for _, rule := range options.ListMergeRules {
+		if err := checkListMerge(rule.Strategy); err != nil {
+			return nil, err
+		}
+		c.listMergeRules = append(c.listMergeRules, listMergeRule{pattern: parsePathPattern(rule.Path), strategy: rule.Strategy})
+	}
+	for _, rule := range options.SortListRules {
+		c.sortListRules = append(c.sortListRules, sortListRule{pattern: parsePathPattern(rule.Path), keys: rule.Keys})
+	}
+	c.documentSortKeys, err = expandDocumentSortKeys(options.SortDocuments)
+	if err != nil {
+		return nil, err
+	}
+	// quote style
+	if len(c.quoteStyle) == 0 {
+		c.quoteStyle = QuoteStyleMinimal
+		if options.QuoteString {
+			c.quoteStyle = QuoteStyleSingle
+		}
+	}
+	if err := checkQuoteStyle(c.quoteStyle); err != nil {
+		return nil, err
+	}
+	for _, rule := range options.QuoteRules {
+		if err := checkQuoteStyle(rule.Style); err != nil {
+			return nil, err
+		}
+		c.quoteRules = append(c.quoteRules, quoteRule{pattern: parsePathPattern(rule.Path), style: rule.Style})
+	}
+	c.yamlVersion, err = checkYAMLVersion(options.YAMLVersion)
+	if err != nil {
+		return nil, err
+	}
+	// indent width and array indent
+	switch {
+	case options.Indent == IndentAuto:
+		c.blnIndentAuto = true
+		c.indent = 2
+	case options.Indent == 0:
+		c.indent = 2
+	case options.Indent > 0:
+		c.indent = options.Indent
+	default:
+		return nil, fmt.Errorf("bad indent:%v", options.Indent)
+	}
+	if options.LineWidth < 0 {
+		return nil, fmt.Errorf("bad line width:%v", options.LineWidth)
+	}
+	sequenceIndent := options.SequenceIndent
+	if len(sequenceIndent) == 0 {
+		switch {
+		case options.ArrayIndentPlus2:
+			sequenceIndent = SequenceIndentIndented
+		case c.blnIndentAuto:
+			sequenceIndent = SequenceIndentAuto
+		default:
+			sequenceIndent = SequenceIndentNonIndented
+		}
+	}
+	switch sequenceIndent {
+	case SequenceIndentIndented:
+		c.blnSequenceIndented = true
+	case SequenceIndentNonIndented:
+	case SequenceIndentAuto:
+		c.blnSequenceAuto = true
+	default:
+		return nil, fmt.Errorf("bad sequence indent:%v (%v , %v or %v)", sequenceIndent, SequenceIndentIndented, SequenceIndentNonIndented, SequenceIndentAuto)
+	}
+	return c, nil
+}
+
+// convert v to yaml node
+func (c *sorter) toNode(v interface{}) (*yaml.Node, error) {
+	if node, ok := v.(*yaml.Node); ok {
+		return node, nil
+	}
+	return c.myDataToNode(v)
+}
+
+// return copy of sorter for one job. error messages of the job are buffered.
+func (c *sorter) newWorker() *sorter {
+	worker := *c
+	worker.jobs = 1
+	worker.stderr = new(bytes.Buffer)
+	worker.outputAnchors = nil
+	worker.blnLastKeepString = false
+	return &worker
+}
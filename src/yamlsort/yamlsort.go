@@ -1,869 +1,731 @@
-//
-// yamlsort - sort by map's key
-//
-//
-//
-package main
-
-import (
-	"bufio"
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"io/ioutil"
-	"os"
-	"reflect"
-	"sort"
-	"strconv"
-	"strings"
-	"unicode"
-
-	"github.com/ghodss/yaml"
-	"github.com/spf13/cobra"
-)
-
-// version string set by ldflags (git describe)
-var version string
-
-var yamlsortUsage = `
-yaml sorter. read yaml text from stdin or file, output map key sorted text to stdout or file.
-`
-
-//---------------------------------------------------------------------
-//  stringMacro class
-// helm chart macro value
-//
-type stringMacro struct {
-	value string
-}
-
-func (c *stringMacro) setString(arg string) {
-}
-func (c *stringMacro) getString() string {
-	return c.value
-}
-
-//---------------------------------------------------------------------
-//  yamlsortCmd class
-//
-type yamlsortCmd struct {
-	stdin               io.Reader
-	stdout              io.Writer
-	stderr              io.Writer
-	inputfilename       string
-	outputfilename      string
-	inputoutputfilename string
-	overridefilename    string
-	skipkeys            []string
-	selectkeys          []string
-	blnInputJSON        bool
-	blnNormalMarshal    bool
-	blnJSONMarshal      bool
-	blnQuoteString      bool
-	blnArrayIndentPlus2 bool
-	priorkeys           []string
-	blnVersion          bool
-	version             string
-}
-
-func newRootCmd(args []string) *cobra.Command {
-
-	yamlsort := &yamlsortCmd{
-		version: version,
-	}
-
-	cmd := &cobra.Command{
-		Use:   "yamlsort",
-		Short: "yaml sorter",
-		Long:  yamlsortUsage,
-		RunE: func(c *cobra.Command, args []string) error {
-			return yamlsort.run(args)
-		},
-	}
-
-	f := cmd.Flags()
-	f.StringVarP(&yamlsort.inputoutputfilename, "input-output-file", "f", "", "path to input/output file name")
-	f.StringVarP(&yamlsort.inputfilename, "input-file", "i", "", "path to input file name")
-	f.StringVarP(&yamlsort.outputfilename, "output-file", "o", "", "path to output file name")
-	f.StringVarP(&yamlsort.overridefilename, "override-file", "", "", "path to override input file name")
-	f.BoolVar(&yamlsort.blnInputJSON, "jsoninput", false, "read JSON data")
-	f.BoolVar(&yamlsort.blnQuoteString, "quote-string", false, "string value is always quoted in output")
-	f.BoolVar(&yamlsort.blnNormalMarshal, "normal", false, "use marshal (github.com/ghodss/yaml)")
-	f.BoolVar(&yamlsort.blnJSONMarshal, "jsonoutput", false, "use json marshal (encoding/json)")
-	f.BoolVar(&yamlsort.blnArrayIndentPlus2, "array-indent-plus-2", false, "output array indent + 2 in yaml format")
-	f.BoolVar(&yamlsort.blnVersion, "version", false, "displays version")
-	f.StringArrayVar(&yamlsort.priorkeys, "key", []string{}, "set prior key name in sort. default prior key is name. (can specify multiple values with --key name --key title)")
-	f.StringArrayVar(&yamlsort.skipkeys, "skip-key", []string{}, "skip key name in marshal output. (can specify multiple values with --skip-key name --skip-key title)")
-	f.StringArrayVar(&yamlsort.selectkeys, "select-key", []string{}, "select key name in marshal output. (can specify multiple values with --select-key name --select-key title)")
-
-	yamlsort.stdin = os.Stdin
-	yamlsort.stdout = os.Stdout
-	yamlsort.stderr = os.Stderr
-
-	return cmd
-}
-
-func main() {
-	cmd := newRootCmd(os.Args[1:])
-	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
-	}
-}
-
-// in my marshal, sort prior key
-var globalpriorkeys []string
-
-//------------------------------------------------------------------------
-// run main
-//
-func (c *yamlsortCmd) run(args []string) error {
-
-	if args != nil {
-		if len(args) > 0 {
-			fmt.Fprintln(c.stdout, "yamlsort version "+c.version)
-			return nil
-		}
-	}
-	if c.blnVersion {
-		fmt.Fprintln(c.stdout, "yamlsort version "+c.version)
-		return nil
-	}
-
-	// override inputoutputfilename
-	if len(c.inputoutputfilename) > 0 {
-		if len(c.inputfilename) == 0 {
-			c.inputfilename = c.inputoutputfilename
-		}
-		if len(c.outputfilename) == 0 {
-			c.outputfilename = c.inputoutputfilename
-		}
-	}
-
-	myReadBytes := []byte{}
-	var err error
-
-	// check prior keys
-	if len(c.priorkeys) == 0 {
-		c.priorkeys = []string{"name"}
-	}
-
-	// set global variable priorkeys
-	globalpriorkeys = c.priorkeys
-
-	// check input-file option
-	if len(c.inputfilename) > 0 {
-		// read from file
-		myReadBytes, err = ioutil.ReadFile(c.inputfilename)
-		if err != nil {
-			return err
-		}
-	} else {
-		// read from stdin
-		myReadBuffer := new(bytes.Buffer)
-		_, err := io.Copy(myReadBuffer, c.stdin)
-		if err != nil {
-			return err
-		}
-		myReadBytes = myReadBuffer.Bytes()
-	}
-
-	// create output buffer
-	outputBuffer := new(bytes.Buffer)
-
-	// setup file scanner
-	reader := bytes.NewReader(myReadBytes)
-	scanner := bufio.NewScanner(reader)
-	onefilebuffer := new(bytes.Buffer)
-	linecount := 0
-	firstlinestr := ""
-	if len(c.inputfilename) > 0 {
-		firstlinestr = "# " + c.inputfilename + "  "
-	}
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "---" {
-			linecount = 0
-
-			// flush outfilebuffer
-			if onefilebuffer.Len() > 0 {
-				// marshal one file
-				err = c.procOneFile(outputBuffer, firstlinestr, onefilebuffer.Bytes())
-				if err != nil {
-					return err
-				}
-				onefilebuffer = new(bytes.Buffer)
-				firstlinestr = ""
-			}
-			continue
-		}
-		linecount++
-		if linecount == 1 {
-			if len(line) > 0 {
-				if strings.HasPrefix(line, "#") {
-					firstlinestr = line + "  "
-				}
-			}
-		}
-		fmt.Fprintln(onefilebuffer, line)
-	}
-	// flush outfilebuffer
-	if onefilebuffer.Len() > 0 {
-		// marshal one file
-		err = c.procOneFile(outputBuffer, firstlinestr, onefilebuffer.Bytes())
-		if err != nil {
-			return err
-		}
-		onefilebuffer = new(bytes.Buffer)
-		firstlinestr = ""
-	}
-
-	// at last, write outputBuffer into file or stdout.
-	// check output-file option
-	outputWriter := c.stdout
-	var flushWriter *bufio.Writer
-	if len(c.outputfilename) > 0 {
-		ofp, err := os.Create(c.outputfilename)
-		if err != nil {
-			return err
-		}
-		defer ofp.Close()
-		flushWriter = bufio.NewWriter(ofp)
-		outputWriter = flushWriter
-	}
-	// do output
-	fmt.Fprint(outputWriter, outputBuffer)
-	// flush
-	if flushWriter != nil {
-		err := flushWriter.Flush()
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-//-------------------------------------------------------------------------------------
-//  unmarshal and sort and marshal.
-//
-func (c *yamlsortCmd) procOneFile(outputWriter io.Writer, firstlinestr string, inputbytes []byte) error {
-	var data interface{}
-
-	if c.blnInputJSON {
-		// parse json data
-		err := json.Unmarshal(inputbytes, &data)
-		if err != nil {
-			fmt.Fprintln(c.stderr, "Unmarshal JSON error:", err)
-			return err
-		}
-	} else {
-		// parse yaml data
-		err := yaml.Unmarshal(inputbytes, &data)
-		if err != nil {
-			fmt.Fprintln(c.stderr, "Unmarshal YAML error:", err)
-			return err
-		}
-	}
-
-	// override
-	if len(c.overridefilename) > 0 {
-		dataOverride, err := c.myLoadFromFile(c.overridefilename)
-		if err != nil {
-			return err
-		}
-		result, err2 := c.myOverride(data, dataOverride)
-		if err2 != nil {
-			return err2
-		}
-		data = result
-	}
-
-	// if firstline contains '# powered by ' , remove it.
-	idx := strings.Index(firstlinestr, "# powered by ")
-	if idx >= 0 {
-		firstlinestr = string([]rune(firstlinestr)[:idx])
-	}
-	if c.blnNormalMarshal {
-		// write yaml data with normal marshal (github.com/ghodss/yaml)
-		outputBytes, err := yaml.Marshal(data)
-		if err != nil {
-			fmt.Fprintln(c.stderr, "Marshal error:", err)
-			return err
-		}
-		fmt.Fprintln(outputWriter, "---")
-		fmt.Fprintf(outputWriter, "%s%s\n", firstlinestr, "# powered by github.com/ghodss/yaml/Marshal")
-		fmt.Fprintln(outputWriter, string(outputBytes))
-	} else if c.blnJSONMarshal {
-		// write json data with normal marshal
-		outputBytes, err := json.MarshalIndent(data, "", "  ")
-		if err != nil {
-			fmt.Fprintln(c.stderr, "Marshal error:", err)
-			return err
-		}
-		// fmt.Fprintln(outputWriter, "---")
-		// fmt.Fprintf(outputWriter, "%s%s\n", firstlinestr, "# powered by json.MarshalIndent output")
-		fmt.Fprintln(outputWriter, string(outputBytes))
-
-	} else {
-		// write yamlsort my marshal
-		outputBytes2, err := c.myMarshal(data)
-		if err != nil {
-			fmt.Fprintln(c.stderr, "myMarshal error:", err)
-			return err
-		}
-		fmt.Fprintln(outputWriter, "---")
-		fmt.Fprintf(outputWriter, "%s%s\n", firstlinestr, "# powered by myMarshal output")
-		fmt.Fprintln(outputWriter, string(outputBytes2))
-	}
-
-	return nil
-}
-
-//-----------------------------------------------------------------------------------
-// my marshal (data to string with sorting map key)
-//
-func (c *yamlsortCmd) myMarshal(data interface{}) ([]byte, error) {
-	// create buffer
-	writer := new(bytes.Buffer)
-	err := c.myMershalRecursive(writer, 0, "", false, data)
-	return writer.Bytes(), err
-}
-
-// return socre of priority key name  , like "name"
-func priorIndex(priorkeys []string, s string) int {
-	for i, v := range priorkeys {
-		if s == v {
-			return i
-		}
-	}
-	return 999999
-}
-
-// convert string to int slice, number is convert to one int.
-func convertStringToUint64Slice(s string) ([]uint64, error) {
-	result := []uint64{}
-	digitBuf := []rune{}
-
-	for _, r := range s {
-		if unicode.IsDigit(r) {
-			digitBuf = append(digitBuf, r)
-		} else {
-			if len(digitBuf) > 0 {
-				i, err := strconv.ParseInt(string(digitBuf), 10, 64)
-				if err != nil {
-					return result, err
-				}
-				result = append(result, uint64(i))
-				digitBuf = []rune{}
-			}
-			// string character (rune) is may be 32bit value (unicode 16)
-			result = append(result, uint64(r)+0x1000000000000000)
-		}
-	}
-	if len(digitBuf) > 0 {
-		i, err := strconv.ParseInt(string(digitBuf), 10, 64)
-		if err != nil {
-			return result, err
-		}
-		result = append(result, uint64(i))
-		digitBuf = []rune{}
-	}
-	return result, nil
-}
-
-// compair string1 string2 , consider prior key name , and string-number-string key
-func compairString(s1 string, s2 string) bool {
-	// priority key name check
-	score1 := priorIndex(globalpriorkeys, s1)
-	score2 := priorIndex(globalpriorkeys, s2)
-	if score1 != score2 {
-		return score1 < score2
-	}
-
-	uint64slice1, err1 := convertStringToUint64Slice(s1)
-	uint64slice2, err2 := convertStringToUint64Slice(s2)
-	if err1 != nil || err2 != nil {
-		return s1 < s2
-	}
-
-	// string compair with string-number-string
-	len1 := len(uint64slice1)
-	len2 := len(uint64slice2)
-	for i := 0; i < len1 && i < len2; i++ {
-		if uint64slice1[i] != uint64slice2[i] {
-			return uint64slice1[i] < uint64slice2[i]
-		}
-	}
-	return len1 < len2
-}
-
-func (c *yamlsortCmd) escapeString(value string) string {
-	blnDoQuote := false
-	blnDoDoubleQuote := false
-
-	// if always quote flag, then quote.
-	if c.blnQuoteString {
-		blnDoQuote = true
-	}
-
-	// if string like boolean , then quote.
-	boolArray := [...]string{"true", "false", "yes", "no", "on", "off"}
-	for _, s := range boolArray {
-		if strings.EqualFold(value, s) {
-			blnDoQuote = true
-		}
-	}
-
-	// if string starts with 0-9 , . , then quote.
-	numberArray := [...]string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", ",", "!", "@", "#", "%", "&", "*", "|", "`", "[", "]", "{", "}"}
-	for _, s := range numberArray {
-		if strings.HasPrefix(value, s) {
-			blnDoQuote = true
-		}
-	}
-
-	// if string contains " or ' , then quote.
-	if strings.Contains(value, "\"") || strings.Contains(value, "'") {
-		blnDoQuote = true
-	}
-
-	// if string contains \r \n \t , then quote.
-	if strings.Contains(value, "\r") || strings.Contains(value, "\n") || strings.Contains(value, "\t") {
-		blnDoQuote = true
-		blnDoDoubleQuote = true
-	}
-
-	// if string contains { or } , then quote.
-	if strings.Contains(value, "{") || strings.Contains(value, "}") {
-		blnDoQuote = true
-	}
-
-	// if string starts space , then quote.
-	if strings.HasPrefix(value, " ") || strings.HasSuffix(value, " ") {
-		blnDoQuote = true
-	}
-
-	// if string starts tab , then quote.
-	if strings.HasPrefix(value, "\t") || strings.HasSuffix(value, "\t") {
-		blnDoQuote = true
-		blnDoDoubleQuote = true
-	}
-
-	// if string length == 0 ,  then quote
-	if len(value) == 0 {
-		blnDoQuote = true
-	}
-	if !blnDoQuote {
-		return value
-	}
-
-	if blnDoDoubleQuote {
-		// quote "
-		result := value
-		result = strings.Replace(result, "\\", "\\\\", -1)
-		result = strings.Replace(result, "\"", "\\\"", -1)
-		result = strings.Replace(result, "\t", "\\t", -1)
-		result = strings.Replace(result, "\n", "\\n", -1)
-		result = strings.Replace(result, "\r", "\\r", -1)
-		result = "\"" + result + "\""
-		return result
-	}
-	// quote '
-	// quote ' .  in quote ' ,  ' is ''
-	result := "'" + strings.Replace(value, "'", "''", -1) + "'"
-	return result
-}
-
-func (c *yamlsortCmd) calcPathMap(path string, key string) string {
-	if len(path) == 0 {
-		return key
-	}
-	return path + "." + key
-}
-
-func (c *yamlsortCmd) calcPathSlice(path string, index int) string {
-	if len(path) == 0 {
-		return "[" + strconv.Itoa(index) + "]"
-	}
-	return path + "[" + strconv.Itoa(index) + "]"
-}
-
-func (c *yamlsortCmd) calcPathSliceMap(path string, key string, value string) string {
-	if len(path) == 0 {
-		return "[" + key + "=" + value + "]"
-	}
-	return path + "[" + key + "=" + value + "]"
-}
-
-func (c *yamlsortCmd) checkSkipKey(path string) bool {
-	for _, s := range c.skipkeys {
-		if len(s) > 0 {
-			if s == path {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func (c *yamlsortCmd) checkSelectKey(path string) bool {
-
-	// 指定が一つもない場合は常に選択OK
-	if len(c.selectkeys) == 0 {
-		return true
-	}
-
-	// 指定がある場合は、指定されたパスの下だけOK
-	for _, s := range c.selectkeys {
-		if len(s) > 0 {
-			// 正解に続く道ならとりあえず許可する。ここで探索を打ち切ると正解にたどり着けないので。
-			if strings.HasPrefix(s, path) {
-				return true
-			}
-			// 正解の下は許可する
-			if strings.HasPrefix(path, s) {
-				return true
-			}
-		}
-	}
-
-	return false
-}
-
-func (c *yamlsortCmd) myMershalRecursive(writer io.Writer, level int, path string, blnParentSlide bool, data interface{}) error {
-	if data == nil {
-		fmt.Fprintln(writer, "null")
-		return nil
-	}
-	if m, ok := data.(map[string]interface{}); ok {
-		// data is map
-
-		// if map has no key , then output {}
-		if len(m) == 0 {
-			indentstr := c.indentstr(level)
-			fmt.Fprintf(writer, "%s%s\n", indentstr, "{}")
-			return nil
-		}
-
-		// get key list
-		var keylist []string
-		for k := range m {
-			keylist = append(keylist, k)
-		}
-
-		// sort map key, but key priorkeys is first
-		sort.Slice(keylist, func(idx1, idx2 int) bool {
-			return compairString(keylist[idx1], keylist[idx2])
-		})
-
-		// recursive call
-		for i, k := range keylist {
-			v := m[k]
-			indentstr := c.indentstr(level)
-			// when parent element is slice and print first key value, no need to indent
-			if blnParentSlide && i == 0 {
-				indentstr = ""
-			}
-			childpath := c.calcPathMap(path, k)
-			// check skip key
-			if c.checkSkipKey(childpath) == true {
-				continue
-			}
-			// heck select key
-			if c.checkSelectKey(childpath) != true {
-				continue
-			}
-			if v == nil {
-				// child is nil. print key only.
-				fmt.Fprintf(writer, "%s%s: ", indentstr, k)
-			} else if _, ok := v.(map[string]interface{}); ok {
-				// child is map
-				fmt.Fprintf(writer, "%s%s:\n", indentstr, k)
-			} else if _, ok := v.([]interface{}); ok {
-				// child is slice
-				fmt.Fprintf(writer, "%s%s:\n", indentstr, k)
-			} else {
-				// child is normal string
-				fmt.Fprintf(writer, "%s%s: ", indentstr, k)
-			}
-			err := c.myMershalRecursive(writer, level+2, childpath, false, v)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	} else if a, ok := data.([]interface{}); ok {
-		// data is slice
-
-		// if array has no data, then output []
-		if len(a) == 0 {
-			indentstr := c.indentstr(level)
-			fmt.Fprintf(writer, "%s%s\n", indentstr, "[]")
-			return nil
-		}
-
-		for i, v := range a {
-			levelOffset := 0
-			if c.blnArrayIndentPlus2 {
-				levelOffset = 2
-			}
-			childpath := c.calcPathSlice(path, i)
-			if tmpmap, ok2 := v.(map[string]interface{}); ok2 {
-				if tmpname, ok3 := tmpmap["name"]; ok3 {
-					if tmpnamestr, ok4 := tmpname.(string); ok4 {
-						// sliceの中は name要素を持つmapの場合、特別なpath [name=value]を生成
-						childpath = c.calcPathSliceMap(path, "name", tmpnamestr)
-					}
-				}
-			}
-			// check skip key
-			if c.checkSkipKey(childpath) == true {
-				continue
-			}
-			// heck select key
-			if c.checkSelectKey(childpath) != true {
-				continue
-			}
-			fmt.Fprintf(writer, "%s- ", c.indentstr(level-2+levelOffset))
-			err := c.myMershalRecursive(writer, level+levelOffset, childpath, true, v)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	} else if s, ok := data.(stringMacro); ok {
-		// data is stringMacro
-		fmt.Fprintln(writer, s.getString())
-	} else if s, ok := data.(string); ok {
-		// data is string
-		fmt.Fprintln(writer, c.escapeString(s))
-	} else if i, ok := data.(int); ok {
-		// data is int
-		fmt.Fprintln(writer, i)
-	} else if f64, ok := data.(float64); ok {
-		// data is float64
-		fmt.Fprintln(writer, f64)
-	} else if b, ok := data.(bool); ok {
-		// data is bool
-		fmt.Fprintln(writer, b)
-	} else {
-		return fmt.Errorf("unknown type:%v  data:%v", reflect.TypeOf(data), data)
-	}
-	return nil
-}
-
-func (c *yamlsortCmd) indentstr(level int) string {
-	result := ""
-	for i := 0; i < level; i++ {
-		result = result + " "
-	}
-	return result
-}
-
-//-------------------------------------------------------------------------
-// my Override
-//
-
-func (c *yamlsortCmd) myOverride(data interface{}, dataOverride interface{}) (interface{}, error) {
-	result, err := c.myOverrideRecursive(data, dataOverride)
-	return result, err
-}
-
-func (c *yamlsortCmd) myOverrideRecursive(data interface{}, dataOverride interface{}) (interface{}, error) {
-	if dataOverride == nil {
-		return data, nil
-	}
-	if data == nil {
-		data = dataOverride
-		return data, nil
-	}
-
-	{
-		// map check
-		mdest, ok1 := data.(map[string]interface{})
-		m, ok2 := dataOverride.(map[string]interface{})
-		if ok1 && ok2 {
-			// dataOverride is map
-			// get key list
-			var keylist []string
-			for k := range m {
-				keylist = append(keylist, k)
-			}
-			// sort map key, but key priorkeys is first
-			sort.Slice(keylist, func(idx1, idx2 int) bool {
-				return compairString(keylist[idx1], keylist[idx2])
-			})
-			// recursive call
-			for _, k := range keylist {
-				vdest := mdest[k]
-				v := m[k]
-				// vdest is nil, then copy and continue
-				if vdest == nil {
-					mdest[k] = v
-					continue
-				}
-				// when parent element is slice and print first key value, no need to indent
-				if v == nil {
-					// value is nil. key only.
-					mdest[k] = v
-					continue
-				} else if _, ok := v.(map[string]interface{}); ok {
-					// value is map
-				} else if _, ok := v.([]interface{}); ok {
-					// value is slice
-					//if adest, ok2 := vdest.([]interface{}); ok2 {
-					//	// dest is slice, so append slice
-					//	adest = append(adest, a...)
-					//	// override map
-					//	mdest[k] = adest
-					//}
-				} else {
-					// value is normal string/float64/int
-					mdest[k] = v
-					continue
-				}
-				result, err := c.myOverrideRecursive(vdest, v)
-				if err != nil {
-					return data, err
-				}
-				mdest[k] = result
-			}
-			return data, nil
-		}
-	}
-	{
-		// slice check ( slice - map type )
-		adest, ok1 := data.([]interface{})
-		a, ok2 := dataOverride.([]interface{})
-		if ok1 && ok2 {
-			blnOverride := false
-
-			// check slice - map["name"] type
-			for _, elem := range a {
-				if m, ok3 := elem.(map[string]interface{}); ok3 {
-					// slice - map
-					name := m["name"]
-					for idest, destelem := range adest {
-						if mdest, ok4 := destelem.(map[string]interface{}); ok4 {
-							// slice - map
-							namedest := mdest["name"]
-							if _, ok5 := namedest.(string); ok5 {
-								if name == namedest {
-									result, err := c.myOverrideRecursive(mdest, m)
-									if err != nil {
-										return data, err
-									}
-									adest[idest] = result
-									blnOverride = true
-								}
-							}
-						}
-					}
-					if blnOverride == false {
-						// append
-						adest = append(adest, m)
-						blnOverride = true
-					}
-				} else if s, ok4 := elem.(string); ok4 {
-					// check []string
-					adest = append(adest, s)
-					blnOverride = true
-				} else if i, ok4 := elem.(int); ok4 {
-					// check []string
-					adest = append(adest, i)
-					blnOverride = true
-				} else if f, ok4 := elem.(float64); ok4 {
-					// check []string
-					adest = append(adest, f)
-					blnOverride = true
-				} else if b, ok4 := elem.(bool); ok4 {
-					// check []string
-					adest = append(adest, b)
-					blnOverride = true
-				}
-			}
-
-			if blnOverride == false {
-				fmt.Printf("unknown slice type:%v  data:%v", reflect.TypeOf(data), data)
-			}
-			return adest, nil
-		}
-	}
-	{
-		// slice check ( slice - string/int/float64/bool type )
-		adest, ok1 := data.([]string)
-		a, ok2 := dataOverride.([]string)
-		if ok1 && ok2 {
-			for _, k := range a {
-				adest = append(adest, k)
-				fmt.Println("append []string ", k)
-			}
-			return adest, nil
-		}
-	}
-	{
-		// slice check ( slice - string/int/float64/bool type )
-		adest, ok1 := data.([]int)
-		a, ok2 := dataOverride.([]int)
-		if ok1 && ok2 {
-			for _, k := range a {
-				adest = append(adest, k)
-			}
-			return adest, nil
-		}
-	}
-	{
-		// slice check ( slice - string/int/float64/bool type )
-		adest, ok1 := data.([]float64)
-		a, ok2 := dataOverride.([]float64)
-		if ok1 && ok2 {
-			for _, k := range a {
-				adest = append(adest, k)
-			}
-			return adest, nil
-		}
-	}
-	{
-		// slice check ( slice - string/int/float64/bool type )
-		adest, ok1 := data.([]bool)
-		a, ok2 := dataOverride.([]bool)
-		if ok1 && ok2 {
-			for _, k := range a {
-				adest = append(adest, k)
-			}
-			return adest, nil
-		}
-	}
-
-	return data, fmt.Errorf("unknown type:%v  data:%v", reflect.TypeOf(data), data)
-}
-
-//-------------------------------------------------------------------------
-// load yaml data from file
-//
-func (c *yamlsortCmd) myLoadFromFile(filename string) (interface{}, error) {
-	var data interface{}
-	// read from file
-	myReadBytes, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return data, err
-	}
-
-	if c.blnInputJSON {
-		// parse json data
-		err := json.Unmarshal(myReadBytes, &data)
-		if err != nil {
-			fmt.Fprintln(c.stderr, "Unmarshal JSON error:", err)
-			return data, err
-		}
-	} else {
-		// parse yaml data
-		err := yaml.Unmarshal(myReadBytes, &data)
-		if err != nil {
-			fmt.Fprintln(c.stderr, "Unmarshal YAML error:", err)
-			return data, err
-		}
-	}
-	return data, nil
-}
+//
+// yamlsort - sort by map's key
+//
+//
+//
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/george-pon/yamlsort/src/yamlsort/pkg/yamlsort"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// version string set by ldflags (git describe)
+var version string
+
+var yamlsortUsage = `
+yaml sorter. read yaml text from stdin or file, output map key sorted text to stdout or file.
+file arguments (file name, glob pattern like "k8s/**/*.yaml", or directory with --recursive) are sorted in place.
+`
+
+//---------------------------------------------------------------------
+//  yamlsortCmd class
+//
+type yamlsortCmd struct {
+	stdin               io.Reader
+	stdout              io.Writer
+	stderr              io.Writer
+	inputfilename       string
+	outputfilename      string
+	inputoutputfilename string
+	overridefilenames   []string
+	blnOverrideReport   bool
+	setvalues           []string
+	setstrings          []string
+	setjsons            []string
+	setfiles            []string
+	skipkeys            []string
+	selectkeys          []string
+	blnInputJSON        bool
+	blnNormalMarshal    bool
+	blnJSONMarshal      bool
+	blnQuoteString      bool
+	quotestyle          string
+	quoterules          []string
+	blnArrayIndentPlus2 bool
+	indent              string
+	sequenceindent      string
+	linewidth           int
+	yamlversion         string
+	blnFlowStyle        bool
+	blnKeepAnchor       bool
+	blnFoldedString     bool
+	blnExpandMergeKey   bool
+	mergekeys           []string
+	listmerge           string
+	listmergerules      []string
+	blnCheck            bool
+	blnDiff             bool
+	blnColor            bool
+	blnRecursive        bool
+	includes            []string
+	excludes            []string
+	outputdir           string
+	jobs                int
+	priorkeys           []string
+	presets             []string
+	keyrules            []string
+	sortlists           []string
+	blnSortListAlpha    bool
+	blnDedupeList       bool
+	sortdocuments       string
+	blnVersion          bool
+	blnPrintConfig      bool
+	version             string
+	flags               *pflag.FlagSet
+	cmdargs             []string
+	configs             *configCache
+	overrideStdin       *stdinCache
+}
+
+//---------------------------------------------------------------------
+//  stdinCache class
+// content of override file "-". stdin is read once , and shared by input files.
+//
+type stdinCache struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+func newRootCmd(args []string) *cobra.Command {
+
+	yamlsort := &yamlsortCmd{
+		version: version,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "yamlsort [flags] [file|pattern|directory ...]",
+		Short: "yaml sorter",
+		Long:  yamlsortUsage,
+		RunE: func(c *cobra.Command, args []string) error {
+			// flags are parsed. usage is not printed for errors at run time.
+			c.SilenceUsage = true
+			err := yamlsort.run(args)
+			if _, ok := err.(*exitStatusError); ok {
+				// message is already printed.
+				c.SilenceErrors = true
+			}
+			return err
+		},
+	}
+
+	yamlsort.flags = cmd.Flags()
+	yamlsort.cmdargs = args
+	yamlsort.addFlags(cmd.Flags())
+	cmd.SetArgs(args)
+
+	yamlsort.stdin = os.Stdin
+	yamlsort.stdout = os.Stdout
+	yamlsort.stderr = os.Stderr
+
+	return cmd
+}
+
+// define command line flags into flag set. flag names are also key names in .yamlsort.yaml.
+func (c *yamlsortCmd) addFlags(f *pflag.FlagSet) {
+	f.StringVarP(&c.inputoutputfilename, "input-output-file", "f", "", "path to input/output file name")
+	f.StringVarP(&c.inputfilename, "input-file", "i", "", "path to input file name")
+	f.StringVarP(&c.outputfilename, "output-file", "o", "", "path to output file name")
+	f.StringArrayVarP(&c.overridefilenames, "override-file", "", []string{}, "path to override input file name. '-' means stdin. files are merged in order. (can specify multiple values)")
+	f.StringArrayVar(&c.setvalues, "set", []string{}, "set value at key path after override files , like 'spec.replicas=3' or 'spec.containers[name=app].image=nginx'. \".\" in key is escaped as \\. , like 'metadata.annotations.app\\.kubernetes\\.io/name=web'. value is read as yaml scalar. (can specify multiple values)")
+	f.StringArrayVar(&c.setstrings, "set-string", []string{}, "set string value at key path , like 'image.tag=1.0'. (can specify multiple values)")
+	f.StringArrayVar(&c.setjsons, "set-json", []string{}, "set json value at key path , like 'resources.limits={\"cpu\":\"100m\"}'. map and array replace base value. (can specify multiple values)")
+	f.StringArrayVar(&c.setfiles, "set-file", []string{}, "set content of file as string value at key path , like 'data.script=run.sh'. (can specify multiple values)")
+	f.BoolVar(&c.blnOverrideReport, "override-report", false, "print key path , value and file name which set it , of each value after override into stderr")
+	f.BoolVar(&c.blnInputJSON, "jsoninput", false, "read JSON data")
+	f.BoolVar(&c.blnQuoteString, "quote-string", false, "string value is always quoted in output. same as --quote-style single")
+	f.StringVar(&c.quotestyle, "quote-style", yamlsort.QuoteStyleMinimal, "quote style of string value. minimal (quote only if needed) , single , double or preserve (keep quote of input)")
+	f.StringArrayVar(&c.quoterules, "quote-at", []string{}, "set quote style of string values at key path , like 'metadata.annotations.*=double'. (can specify multiple values)")
+	f.StringVar(&c.yamlversion, "yaml-version", yamlsort.YAMLVersion11, "yaml version of output , 1.1 or 1.2. string read as other type by the version (like yes , 0777 in 1.1) is quoted")
+	f.BoolVar(&c.blnNormalMarshal, "normal", false, "use marshal (github.com/ghodss/yaml)")
+	f.BoolVar(&c.blnJSONMarshal, "jsonoutput", false, "use json marshal (encoding/json)")
+	f.BoolVar(&c.blnArrayIndentPlus2, "array-indent-plus-2", false, "output array indent + 2 in yaml format. same as --sequence-indent indented")
+	f.StringVar(&c.indent, "indent", "2", "indent width of map in yaml format , like 2 or 4. auto keeps indent of each input file")
+	f.IntVar(&c.linewidth, "line-width", 0, "line width in yaml format. long plain string is folded at spaces. 0 means no folding")
+	f.BoolVar(&c.blnFlowStyle, "flow-style", false, "output array and map which have only scalar values in flow style ([a, b] , {cpu: 100m}) , when it fits in --line-width (80 if not set)")
+	f.StringVar(&c.sequenceindent, "sequence-indent", "", "array indent in yaml format. indented , non-indented or auto (keep indent of each input file). default is non-indented (auto with --indent auto)")
+	f.BoolVar(&c.blnKeepAnchor, "keep-anchor", false, "keep anchor (&name) and alias (*name) in myMarshal output")
+	f.BoolVar(&c.blnExpandMergeKey, "expand-merge-key", false, "expand merge key (<<) into plain map")
+	f.StringArrayVar(&c.mergekeys, "merge-key", []string{}, "set identity key of map items in array at key path , like 'spec.**.ports=containerPort+protocol'. used in override and [key=value] of --skip-key , --select-key. default is name. (can specify multiple values)")
+	f.StringVar(&c.listmerge, "list-merge", yamlsort.ListMergeByKey, "merge strategy of array in override. merge-by-key , replace , append , prepend , union (append without duplicate items) or index (merge item N with item N)")
+	f.StringArrayVar(&c.listmergerules, "list-merge-at", []string{}, "set merge strategy of array at key path , like 'spec.**.args=replace'. prior to --list-merge. (can specify multiple values)")
+	f.BoolVar(&c.blnFoldedString, "folded-string", false, "output multi-line string in folded style (>) instead of literal style (|)")
+	f.BoolVar(&c.blnCheck, "check", false, "check input is already sorted. print file name and exit with status 2 if sorting changes it. write nothing")
+	f.BoolVar(&c.blnDiff, "diff", false, "print unified diff of input and sorted output. write nothing")
+	f.BoolVar(&c.blnColor, "color", false, "colorize --diff output")
+	f.BoolVarP(&c.blnRecursive, "recursive", "r", false, "process yaml files in directory arguments recursively")
+	f.StringArrayVar(&c.includes, "include", []string{}, "file name pattern to process in directory. default is *.yaml and *.yml (*.json with --jsoninput). (can specify multiple values)")
+	f.StringArrayVar(&c.excludes, "exclude", []string{}, "file name pattern not to process. (can specify multiple values)")
+	f.StringVarP(&c.outputdir, "output-dir", "", "", "write output files into this directory with same relative path , instead of rewriting file arguments in place")
+	f.IntVarP(&c.jobs, "jobs", "j", 1, "number of files (or documents in one file) processed in parallel. 0 means number of CPUs")
+	f.BoolVar(&c.blnVersion, "version", false, "displays version")
+	f.BoolVar(&c.blnPrintConfig, "print-config", false, "print effective settings of each input file (command line flags and .yamlsort.yaml) , and exit")
+	f.StringArrayVar(&c.priorkeys, "key", []string{}, "set prior key name in sort. default prior key is name. (can specify multiple values with --key name --key title)")
+	f.StringArrayVar(&c.keyrules, "key-at", []string{}, "set prior key names of maps at key path , like 'spec.template.spec.containers[*]=name,image' or '.=kind,apiVersion' (top level). (can specify multiple values)")
+	f.StringArrayVar(&c.sortlists, "sort-list", []string{}, "sort array at key path. 'spec.**.env=name' sorts maps by name , 'metadata.finalizers' sorts scalars. other arrays keep order. (can specify multiple values)")
+	f.BoolVar(&c.blnSortListAlpha, "sort-list-alphabetical", false, "sort array items of --sort-list in alphabetical order , instead of natural order (item2 < item10)")
+	f.BoolVar(&c.blnDedupeList, "dedupe-list", false, "remove duplicate items in arrays of --sort-list")
+	f.StringVar(&c.sortdocuments, "sort-documents", "", "sort documents in stream by comma separated key paths , like 'kind,metadata.name'. 'kubernetes' sorts by kubernetes install order of kind , metadata.namespace and metadata.name")
+	f.StringArrayVar(&c.presets, "preset", []string{}, "use built-in key order preset. kubernetes , helm (Chart.yaml) , docker-compose , github-actions. (can specify multiple values)")
+	f.StringArrayVar(&c.skipkeys, "skip-key", []string{}, "skip key name in marshal output. (can specify multiple values with --skip-key name --skip-key title)")
+	f.StringArrayVar(&c.selectkeys, "select-key", []string{}, "select key name in marshal output. (can specify multiple values with --select-key name --select-key title)")
+}
+
+//---------------------------------------------------------------------
+//  exitStatusError class
+// exit with status other than 1 , like --check result
+//
+type exitStatusError struct {
+	status  int
+	message string
+}
+
+func (e *exitStatusError) Error() string {
+	return e.message
+}
+
+// exit status of --check , when input is not sorted
+const exitStatusNotSorted = 2
+
+func main() {
+	cmd := newRootCmd(os.Args[1:])
+	if err := cmd.Execute(); err != nil {
+		if e, ok := err.(*exitStatusError); ok {
+			os.Exit(e.status)
+		}
+		os.Exit(1)
+	}
+}
+
+//------------------------------------------------------------------------
+// run main
+//
+func (c *yamlsortCmd) run(args []string) error {
+
+	if c.blnVersion {
+		fmt.Fprintln(c.stdout, "yamlsort version "+c.version)
+		return nil
+	}
+
+	// settings of .yamlsort.yaml in current directory , like --recursive , --jobs
+	if c.configs == nil {
+		c.configs = newConfigCache()
+	}
+	if c.overrideStdin == nil {
+		c.overrideStdin = &stdinCache{}
+	}
+	if c.flags != nil {
+		base, _, err := c.fileCmd("")
+		if err != nil {
+			return err
+		}
+		*c = *base
+	}
+
+	// override inputoutputfilename
+	if len(c.inputoutputfilename) > 0 {
+		if len(c.inputfilename) == 0 {
+			c.inputfilename = c.inputoutputfilename
+		}
+		if len(c.outputfilename) == 0 {
+			c.outputfilename = c.inputoutputfilename
+		}
+	}
+
+	// check rules and names in flags
+	if err := c.checkFlags(); err != nil {
+		return err
+	}
+
+	// check jobs
+	if c.jobs < 0 {
+		return fmt.Errorf("--jobs must be 0 or more")
+	}
+	if c.jobs == 0 {
+		c.jobs = runtime.NumCPU()
+	}
+
+	// print effective settings , and exit
+	if c.blnPrintConfig {
+		return c.runPrintConfig(args)
+	}
+
+	// positional arguments are input files
+	if len(args) > 0 {
+		if len(c.inputfilename) > 0 || len(c.outputfilename) > 0 {
+			return fmt.Errorf("can not use --input-file , --output-file , --input-output-file with file arguments")
+		}
+		return c.runFiles(args)
+	}
+	if len(c.outputdir) > 0 {
+		return fmt.Errorf("--output-dir needs file arguments")
+	}
+
+	fc, err := c.configCmd(c.inputfilename)
+	if err != nil {
+		return err
+	}
+	blnChanged, err := fc.procFile(c.inputfilename, c.outputfilename)
+	if err != nil {
+		return err
+	}
+
+	// check mode. print file name if sorting changes it.
+	if c.blnCheck && blnChanged {
+		filename := c.inputfilename
+		if len(filename) == 0 {
+			filename = "<stdin>"
+		}
+		if !c.blnDiff {
+			fmt.Fprintln(c.stdout, filename)
+		}
+		return &exitStatusError{status: exitStatusNotSorted, message: filename + " is not sorted"}
+	}
+
+	return nil
+}
+
+// check values of flags , like rules and preset names
+func (c *yamlsortCmd) checkFlags() error {
+	// check prior key rules
+	for _, s := range c.keyrules {
+		if _, err := yamlsort.ParsePriorKeyRule(s); err != nil {
+			return err
+		}
+	}
+
+	// check sort list rules
+	for _, s := range c.sortlists {
+		if _, err := yamlsort.ParseSortListRule(s); err != nil {
+			return err
+		}
+	}
+
+	// check merge key rules
+	for _, s := range c.mergekeys {
+		if _, err := yamlsort.ParseMergeKeyRule(s); err != nil {
+			return err
+		}
+	}
+
+	// check list merge strategy and rules
+	listMerges := []string{yamlsort.ListMergeByKey, yamlsort.ListMergeReplace, yamlsort.ListMergeAppend, yamlsort.ListMergePrepend, yamlsort.ListMergeUnion, yamlsort.ListMergeIndex}
+	if !containsString(listMerges, c.listmerge) {
+		return fmt.Errorf("unknown list merge strategy:%v (merge-by-key , replace , append , prepend , union or index)", c.listmerge)
+	}
+	for _, s := range c.listmergerules {
+		if _, err := yamlsort.ParseListMergeRule(s); err != nil {
+			return err
+		}
+	}
+
+	// check set values
+	if _, err := c.setValueList(false); err != nil {
+		return err
+	}
+
+	// check quote style and rules
+	quoteStyles := []string{yamlsort.QuoteStyleMinimal, yamlsort.QuoteStyleSingle, yamlsort.QuoteStyleDouble, yamlsort.QuoteStylePreserve}
+	if !containsString(quoteStyles, c.quotestyle) {
+		return fmt.Errorf("unknown quote style:%v (minimal , single , double or preserve)", c.quotestyle)
+	}
+	for _, s := range c.quoterules {
+		if _, err := yamlsort.ParseQuoteRule(s); err != nil {
+			return err
+		}
+	}
+
+	// check presets
+	for _, preset := range c.presets {
+		if !containsString(yamlsort.PresetNames(), preset) {
+			return fmt.Errorf("unknown preset:%v (presets are %v)", preset, strings.Join(yamlsort.PresetNames(), ", "))
+		}
+	}
+
+	// check indent
+	if _, err := yamlsort.ParseIndent(c.indent); err != nil {
+		return err
+	}
+	sequenceIndents := []string{"", yamlsort.SequenceIndentIndented, yamlsort.SequenceIndentNonIndented, yamlsort.SequenceIndentAuto}
+	if !containsString(sequenceIndents, c.sequenceindent) {
+		return fmt.Errorf("unknown sequence indent:%v (indented , non-indented or auto)", c.sequenceindent)
+	}
+
+	// check yaml version
+	if !containsString([]string{yamlsort.YAMLVersion11, yamlsort.YAMLVersion12}, c.yamlversion) {
+		return fmt.Errorf("unknown yaml version:%v (1.1 or 1.2)", c.yamlversion)
+	}
+
+	// check line width
+	if c.linewidth < 0 {
+		return fmt.Errorf("--line-width must be 0 or more")
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------
+//  process many files. print summary into stderr.
+//
+func (c *yamlsortCmd) runFiles(args []string) error {
+	files, err := c.collectFiles(args)
+	if err != nil {
+		fmt.Fprintln(c.stderr, "Error:", err)
+		return &exitStatusError{status: 1, message: err.Error()}
+	}
+
+	// process files with worker pool. result is counted in file order.
+	type fileResult struct {
+		blnChanged bool
+		blnFailed  bool
+	}
+	results := make([]fileResult, len(files))
+	job := func(worker *yamlsortCmd, index int) {
+		file := files[index]
+		// default is in place
+		outputfilename := file.path
+		if len(worker.outputdir) > 0 && !worker.blnCheck && !worker.blnDiff {
+			if file.rel == ".." || strings.HasPrefix(file.rel, ".."+string(filepath.Separator)) || filepath.IsAbs(file.rel) {
+				fmt.Fprintln(worker.stderr, "Error:", file.path+": file is outside of current directory. can not use --output-dir")
+				results[index].blnFailed = true
+				return
+			}
+			outputfilename = filepath.Join(worker.outputdir, file.rel)
+			err := os.MkdirAll(filepath.Dir(outputfilename), 0755)
+			if err != nil {
+				fmt.Fprintln(worker.stderr, "Error:", err)
+				results[index].blnFailed = true
+				return
+			}
+		}
+
+		fc, err := worker.configCmd(file.path)
+		if err != nil {
+			fmt.Fprintln(worker.stderr, "Error:", file.path+":", err)
+			results[index].blnFailed = true
+			return
+		}
+		blnChanged, err := fc.procFile(file.path, outputfilename)
+		if err != nil {
+			fmt.Fprintln(worker.stderr, "Error:", file.path+":", err)
+			results[index].blnFailed = true
+			return
+		}
+		results[index].blnChanged = blnChanged
+	}
+
+	countChanged := 0
+	countUnchanged := 0
+	countFailed := 0
+	done := func(index int) {
+		switch {
+		case results[index].blnFailed:
+			countFailed++
+		case results[index].blnChanged:
+			countChanged++
+			if c.blnCheck && !c.blnDiff {
+				fmt.Fprintln(c.stdout, files[index].path)
+			}
+		default:
+			countUnchanged++
+		}
+	}
+	c.runParallel(len(files), job, done)
+
+	fmt.Fprintf(c.stderr, "yamlsort: %d changed, %d unchanged, %d failed\n", countChanged, countUnchanged, countFailed)
+
+	if countFailed > 0 {
+		return &exitStatusError{status: 1, message: fmt.Sprintf("%d files failed", countFailed)}
+	}
+	if c.blnCheck && countChanged > 0 {
+		return &exitStatusError{status: exitStatusNotSorted, message: fmt.Sprintf("%d files are not sorted", countChanged)}
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------
+//  read input file (or stdin), marshal it, and write into output file (or stdout).
+//  return true if output differs from input.
+//  in --check and --diff mode, write nothing. file is not rewritten if output is same as input.
+//
+func (c *yamlsortCmd) procFile(inputfilename string, outputfilename string) (bool, error) {
+	// read whole input. no limit of line length.
+	myReadBytes, err := c.myReadInput(inputfilename)
+	if err != nil {
+		return false, err
+	}
+
+	// create output buffer
+	outputBuffer := new(bytes.Buffer)
+
+	// marshal each document in stream
+	options := c.sortOptions()
+	options.FileName = inputfilename
+	if containsString(c.overridefilenames, "-") {
+		options.OverrideStdin, err = c.readOverrideStdin(inputfilename)
+		if err != nil {
+			return false, err
+		}
+	}
+	options.SetValues, err = c.setValueList(true)
+	if err != nil {
+		return false, err
+	}
+	err = yamlsort.Sort(bytes.NewReader(myReadBytes), outputBuffer, options)
+	if err != nil {
+		return false, err
+	}
+	blnChanged := !bytes.Equal(myReadBytes, outputBuffer.Bytes())
+
+	// diff mode. print diff of input and output, and write nothing.
+	if c.blnDiff {
+		filename := inputfilename
+		if len(filename) == 0 {
+			filename = "<stdin>"
+		}
+		writeUnifiedDiff(c.stdout, filename, myReadBytes, outputBuffer.Bytes(), c.blnColor)
+	}
+
+	// check mode. write nothing.
+	if c.blnCheck || c.blnDiff {
+		return blnChanged, nil
+	}
+
+	// in place and nothing changed. keep file as is.
+	if len(outputfilename) > 0 && outputfilename == inputfilename && !blnChanged {
+		return blnChanged, nil
+	}
+
+	// at last, write outputBuffer into file or stdout.
+	// check output-file option
+	outputWriter := c.stdout
+	var flushWriter *bufio.Writer
+	if len(outputfilename) > 0 {
+		ofp, err := os.Create(outputfilename)
+		if err != nil {
+			return blnChanged, err
+		}
+		defer ofp.Close()
+		flushWriter = bufio.NewWriter(ofp)
+		outputWriter = flushWriter
+	}
+	// do output
+	fmt.Fprint(outputWriter, outputBuffer)
+	// flush
+	if flushWriter != nil {
+		err := flushWriter.Flush()
+		if err != nil {
+			return blnChanged, err
+		}
+	}
+
+	return blnChanged, nil
+}
+
+// return option of sort library from command line flags
+func (c *yamlsortCmd) sortOptions() yamlsort.Options {
+	// checked in checkFlags
+	indent, _ := yamlsort.ParseIndent(c.indent)
+	quoteStyle := c.quotestyle
+	if c.blnQuoteString && quoteStyle == yamlsort.QuoteStyleMinimal {
+		quoteStyle = yamlsort.QuoteStyleSingle
+	}
+	return yamlsort.Options{
+		MergeOptions: yamlsort.MergeOptions{
+			ExpandMergeKey: c.blnExpandMergeKey,
+			MergeKeys:      c.mergeKeyRules(),
+			ListMerge:      c.listmerge,
+			ListMergeRules: c.listMergeRules(),
+		},
+		PriorKeys:            c.priorkeys,
+		PriorKeyRules:        c.priorKeyRules(),
+		Presets:              c.presets,
+		SortListRules:        c.sortListRules(),
+		SortListAlphabetical: c.blnSortListAlpha,
+		DedupeList:           c.blnDedupeList,
+		SortDocuments:        c.documentSortKeys(),
+		SkipKeys:             c.skipkeys,
+		SelectKeys:           c.selectkeys,
+		InputJSON:            c.blnInputJSON,
+		NormalMarshal:        c.blnNormalMarshal,
+		JSONMarshal:          c.blnJSONMarshal,
+		QuoteString:          c.blnQuoteString,
+		QuoteStyle:           quoteStyle,
+		QuoteRules:           c.quoteRules(),
+		ArrayIndentPlus2:     c.blnArrayIndentPlus2,
+		Indent:               indent,
+		SequenceIndent:       c.sequenceindent,
+		LineWidth:            c.linewidth,
+		YAMLVersion:          c.yamlversion,
+		FlowStyle:            c.blnFlowStyle,
+		KeepAnchor:           c.blnKeepAnchor,
+		FoldedString:         c.blnFoldedString,
+		OverrideFiles:        c.overridefilenames,
+		OverrideReport:       c.blnOverrideReport,
+		Jobs:                 c.jobs,
+		ErrorWriter:          c.stderr,
+	}
+}
+
+// return --key-at rules
+func (c *yamlsortCmd) priorKeyRules() []yamlsort.PriorKeyRule {
+	rules := []yamlsort.PriorKeyRule{}
+	for _, s := range c.keyrules {
+		rule, err := yamlsort.ParsePriorKeyRule(s)
+		if err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// return --sort-list rules
+func (c *yamlsortCmd) sortListRules() []yamlsort.SortListRule {
+	rules := []yamlsort.SortListRule{}
+	for _, s := range c.sortlists {
+		rule, err := yamlsort.ParseSortListRule(s)
+		if err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// return --merge-key rules. rules are checked in checkFlags.
+func (c *yamlsortCmd) mergeKeyRules() []yamlsort.MergeKeyRule {
+	rules := []yamlsort.MergeKeyRule{}
+	for _, s := range c.mergekeys {
+		rule, err := yamlsort.ParseMergeKeyRule(s)
+		if err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// return --list-merge-at rules. rules are checked in checkFlags.
+func (c *yamlsortCmd) listMergeRules() []yamlsort.ListMergeRule {
+	rules := []yamlsort.ListMergeRule{}
+	for _, s := range c.listmergerules {
+		rule, err := yamlsort.ParseListMergeRule(s)
+		if err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// return --quote-at rules. rules are checked in checkFlags.
+func (c *yamlsortCmd) quoteRules() []yamlsort.QuoteRule {
+	rules := []yamlsort.QuoteRule{}
+	for _, s := range c.quoterules {
+		rule, err := yamlsort.ParseQuoteRule(s)
+		if err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// return --sort-documents keys
+func (c *yamlsortCmd) documentSortKeys() []string {
+	if len(c.sortdocuments) == 0 {
+		return nil
+	}
+	return strings.Split(c.sortdocuments, ",")
+}
+
+// return true if list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// return --set-json , --set , --set-string , --set-file values , in this order.
+// content of --set-file is read when blnReadFile is true.
+func (c *yamlsortCmd) setValueList(blnReadFile bool) ([]yamlsort.SetValue, error) {
+	result := []yamlsort.SetValue{}
+	lists := []struct {
+		values  []string
+		setType string
+	}{
+		{c.setjsons, yamlsort.SetTypeJSON},
+		{c.setvalues, yamlsort.SetTypeAuto},
+		{c.setstrings, yamlsort.SetTypeString},
+		{c.setfiles, yamlsort.SetTypeString},
+	}
+	for n, list := range lists {
+		for _, s := range list.values {
+			set, err := yamlsort.ParseSetValue(s, list.setType)
+			if err != nil {
+				return nil, err
+			}
+			if n == len(lists)-1 && blnReadFile {
+				// --set-file path=filename
+				readBytes, err := ioutil.ReadFile(set.Value)
+				if err != nil {
+					return nil, err
+				}
+				set.Value = string(readBytes)
+			}
+			result = append(result, set)
+		}
+	}
+	return result, nil
+}
+
+// return content of override file "-". stdin is read at first call.
+func (c *yamlsortCmd) readOverrideStdin(inputfilename string) ([]byte, error) {
+	if len(inputfilename) == 0 {
+		return nil, fmt.Errorf("can not read both input and override file from stdin. use --input-file or file arguments")
+	}
+	if c.overrideStdin == nil {
+		c.overrideStdin = &stdinCache{}
+	}
+	c.overrideStdin.once.Do(func() {
+		c.overrideStdin.data, c.overrideStdin.err = ioutil.ReadAll(c.stdin)
+		if c.overrideStdin.err != nil {
+			c.overrideStdin.err = fmt.Errorf("read stdin: %v", c.overrideStdin.err)
+		}
+	})
+	return c.overrideStdin.data, c.overrideStdin.err
+}
+
+//-------------------------------------------------------------------------------------
+//  read all bytes from input file or stdin.
+//
+func (c *yamlsortCmd) myReadInput(inputfilename string) ([]byte, error) {
+	// check input-file option
+	if len(inputfilename) > 0 {
+		// read from file
+		myReadBytes, err := ioutil.ReadFile(inputfilename)
+		if err != nil {
+			return nil, err
+		}
+		return myReadBytes, nil
+	}
+	// read from stdin
+	myReadBuffer := new(bytes.Buffer)
+	_, err := io.Copy(myReadBuffer, c.stdin)
+	if err != nil {
+		return nil, fmt.Errorf("read stdin: %v", err)
+	}
+	return myReadBuffer.Bytes(), nil
+}
+
@@ -0,0 +1,2 @@
+echo start
+exec app
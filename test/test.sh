@@ -151,11 +151,177 @@ function f-test-convert-json() {
     fi
 }
 
+#
+#  テスト実施(long line)
+#  64KiB を超える行があっても、後続の行が出力されること。
+#
+function f-test-long-line() {
+    local input_file=${TMPDIR:-/tmp}/yamlsort-long-line-$$.yaml
+    local output_file=${TMPDIR:-/tmp}/yamlsort-long-line-$$-out.yaml
+
+    {
+        echo "kind: Secret"
+        echo -n "data: "
+        head -c 100000 /dev/zero | tr '\0' 'a'
+        echo
+        echo "type: Opaque"
+    } > $input_file
+
+    f-test-success yamlsort -i $input_file -o $output_file
+    f-test-success grep -q "^type: Opaque$" $output_file
+    rm -f $input_file $output_file
+}
+
+#
+#  テスト実施(diff output)
+#
+function f-test-diff() {
+    local input_file=$1
+    shift
+    local other_opt="$@"
+    local base_file_name=${input_file%%.yaml}
+    local output_file=out1/${base_file_name}-diff.txt
+    local answer_file=ans1/${base_file_name}-diff.txt
+
+    mkdir -p out1 ans1
+
+    yamlsort -i $input_file --diff ${other_opt} > $output_file
+    if [ -f $answer_file ]; then
+        if diff -u $answer_file $output_file ; then
+            echo "diff SUCCESS"
+            TEST_SUCCESS_COUNT=$(( $TEST_SUCCESS_COUNT + 1 ))
+        else
+            echo "diff $answer_file $output_file FAILURE"
+            TEST_FAILURE_COUNT=$(( $TEST_FAILURE_COUNT + 1 ))
+        fi
+    else
+        cp $output_file $answer_file
+    fi
+}
+
+#
+#  テスト実施(override files)
+#  複数のoverride fileを順に適用し、標準入力からも読み込めること。
+#
+function f-test-override-files() {
+    local input_file=$1
+    shift
+    local other_opt="$@"
+    local base_file_name=${input_file%%.yaml}
+    local override_file1=${base_file_name}-override1.yaml
+    local override_file2=${base_file_name}-override2.yaml
+    local output_file=out1/${base_file_name}-out.yaml
+    local answer_file=ans1/${base_file_name}-ans.yaml
+    local report_file=out1/${base_file_name}-report.txt
+    local report_answer_file=ans1/${base_file_name}-report.txt
+
+    mkdir -p out1 ans1
+
+    # second override file is read from stdin
+    f-test-success yamlsort -i $input_file -o $output_file --override-file $override_file1 --override-file - --override-report ${other_opt} < $override_file2 2> $report_file
+    # input and override file can not be read from stdin at once
+    f-test-failure yamlsort --override-file - < $input_file
+    for i in $output_file:$answer_file $report_file:$report_answer_file
+    do
+        local out=${i%%:*}
+        local ans=${i##*:}
+        if [ -f $ans ]; then
+            if diff -u $ans $out ; then
+                echo "diff SUCCESS"
+                TEST_SUCCESS_COUNT=$(( $TEST_SUCCESS_COUNT + 1 ))
+            else
+                echo "diff $ans $out FAILURE"
+                TEST_FAILURE_COUNT=$(( $TEST_FAILURE_COUNT + 1 ))
+            fi
+        else
+            cp $out $ans
+        fi
+    done
+}
+
+#
+#  テスト実施(multiple files)
+#  ディレクトリ配下のファイルをまとめて処理できること。
+#
+function f-test-files() {
+    local work_dir=${TMPDIR:-/tmp}/yamlsort-files-$$
+
+    rm -rf $work_dir
+    mkdir -p $work_dir/k8s/app $work_dir/k8s/skip
+    cp sample1.yaml $work_dir/k8s/sample1.yaml
+    cp sample2.yaml $work_dir/k8s/app/sample2.yml
+    cp sample3.yaml $work_dir/k8s/skip/sample3.yaml
+    cp sample4.yaml $work_dir/k8s/app/sample4.txt
+
+    f-test-failure yamlsort $work_dir/k8s
+    f-test-failure yamlsort --check -r $work_dir/k8s
+    f-test-success yamlsort -r $work_dir/k8s --exclude 'skip/**'
+    f-test-success yamlsort --check "$work_dir/k8s/**/*.y*ml" --exclude 'skip/**'
+    f-test-failure yamlsort --check --jobs 4 -r $work_dir/k8s
+    f-test-success cmp sample3.yaml $work_dir/k8s/skip/sample3.yaml
+    f-test-success cmp sample4.yaml $work_dir/k8s/app/sample4.txt
+    ( cd $work_dir && f-test-success yamlsort -r k8s --output-dir out )
+    f-test-success cmp $work_dir/k8s/app/sample2.yml $work_dir/out/k8s/app/sample2.yml
+    f-test-success test -f $work_dir/out/k8s/skip/sample3.yaml
+    rm -rf $work_dir
+}
+
+function f-test-config() {
+    local work_dir=${TMPDIR:-/tmp}/yamlsort-config-$$
+
+    rm -rf $work_dir
+    mkdir -p $work_dir/sub
+    cat > $work_dir/.yamlsort.yaml << "EOF"
+key:
+- title
+overrides:
+- files: [ "sub/**" ]
+  array-indent-plus-2: true
+EOF
+    cp sample1.yaml $work_dir/sample1.yaml
+    cp sample1.yaml $work_dir/sub/sample1.yaml
+
+    pushd $work_dir > /dev/null
+    f-test-success yamlsort sample1.yaml sub/sample1.yaml
+    f-test-success yamlsort --check sample1.yaml sub/sample1.yaml
+    # command line flags are prior to .yamlsort.yaml
+    f-test-failure yamlsort --check --key name sample1.yaml
+    f-test-failure yamlsort --check --array-indent-plus-2=false sub/sample1.yaml
+    f-test-success yamlsort --print-config sample1.yaml sub/sample1.yaml
+    echo "unknown-flag: true" > sub/.yamlsort.yaml
+    f-test-failure yamlsort sub/sample1.yaml
+    popd > /dev/null
+    rm -rf $work_dir
+}
+
+function f-test-config-path() {
+    local work_dir=${TMPDIR:-/tmp}/yamlsort-config-path-$$
+
+    rm -rf $work_dir
+    mkdir -p $work_dir/project $work_dir/run
+    cat > $work_dir/project/.yamlsort.yaml << "EOF"
+override-file: patch.yaml
+set-file:
+- data.script=script.sh
+EOF
+    printf 'spec:\n  replicas: 3\n' > $work_dir/project/patch.yaml
+    printf 'echo start\n' > $work_dir/project/script.sh
+    printf 'data:\n  script: none\nspec:\n  replicas: 1\n' > $work_dir/project/app.yaml
+
+    # file path in .yamlsort.yaml is relative to the directory of it , not current directory
+    pushd $work_dir/run > /dev/null
+    f-test-success yamlsort -i ../project/app.yaml -o app-out.yaml
+    f-test-success grep -q "replicas: 3" app-out.yaml
+    f-test-success grep -q "echo start" app-out.yaml
+    popd > /dev/null
+    rm -rf $work_dir
+}
+
 TEST_SUCCESS_COUNT=0
 TEST_FAILURE_COUNT=0
 
 f-log "version"
-f-test-success yamlsort version
+f-test-success yamlsort --version
 
 f-log "convert "
 f-test-convert  sample.yaml
@@ -206,5 +372,158 @@ f-test-convert  sample14.yaml
 f-log "convert 15 : check yaml here document and jsonoutput"
 f-test-convert-json  sample15.yaml
 
+f-log "convert 16 : check comments move with map key"
+f-test-convert  sample16.yaml
+
+f-log "convert 17 : check multi document stream. directive, document end, --- with comment"
+f-test-convert  sample17.yaml
+
+f-log "convert 18 : check number is output as written"
+f-test-convert  sample18.yaml
+
+f-log "convert 19 : check number in jsonoutput"
+f-test-convert-json  sample19.yaml
+
+f-log "convert 20 : check --keep-anchor option. override into merged key."
+f-test-convert  sample20.yaml --keep-anchor
+
+f-log "convert 21 : check --expand-merge-key option"
+f-test-convert  sample21.yaml --expand-merge-key
+
+f-log "convert 22 : check multi-line string in literal style"
+f-test-convert  sample22.yaml
+
+f-log "convert 23 : check --folded-string option"
+f-test-convert  sample23.yaml --folded-string
+
+f-log "convert 24 : check line longer than 64KiB"
+f-test-long-line
+
+f-log "convert 25 : check --jobs option. output order of documents is kept."
+f-test-convert  sample17.yaml --jobs 4
+
+f-log "convert 26 : check --preset kubernetes option"
+f-test-convert  sample24.yaml --preset kubernetes
+
+f-log "convert 27 : check --preset github-actions option"
+f-test-convert  sample25.yaml --preset github-actions
+
+f-log "convert 28 : check --key-at option. --key-at is prior to --preset."
+f-test-convert  sample26.yaml --preset kubernetes --key-at '.=kind,apiVersion' --key-at 'spec.template.spec.containers[*]=image,name' --key-at 'spec.**.env[*]=value'
+
+f-log "convert 29 : check --sort-list and --dedupe-list option"
+f-test-convert  sample27.yaml --sort-list 'spec.**.env=name' --sort-list 'spec.**.ports=containerPort' --sort-list metadata.finalizers --dedupe-list
+
+f-log "convert 30 : check --sort-documents option. header comment moves with document."
+f-test-convert  sample28.yaml --sort-documents kubernetes --preset kubernetes
+
+f-log "convert 31 : check --indent auto option. indent width and array indent of input are kept."
+f-test-convert  sample29.yaml --indent auto
+
+f-log "convert 32 : check --indent 4 and --sequence-indent indented option. nested array is indented."
+f-test-convert  sample30.yaml --indent 4 --sequence-indent indented
+
+f-log "convert 33 : check --line-width and --flow-style option."
+f-test-convert  sample31.yaml --line-width 60 --flow-style
+
+f-log "convert 34 : string quote test. string read as other type in yaml 1.1 is quoted."
+f-test-convert  sample32.yaml
+
+f-log "convert 35 : string quote test. check --yaml-version 1.2 option."
+f-test-convert  sample33.yaml --yaml-version 1.2
+
+f-log "convert 36 : check --quote-style preserve and --quote-at option."
+f-test-convert  sample34.yaml --quote-style preserve --quote-at 'metadata.annotations.*=double'
+
+f-log "convert 37 : override test. check --merge-key option with composite key , and [key=value] path of --skip-key."
+f-test-convert  sample35.yaml --merge-key 'spec.**.ports=containerPort+protocol' --merge-key '**.volumeMounts=mountPath' --merge-key '**.tolerations=key' --skip-key 'spec.template.spec.containers[name=app].ports[containerPort=80,protocol=TCP].name'
+
+f-log "convert 38 : override test. check --list-merge and --list-merge-at option."
+f-test-convert  sample36.yaml --list-merge union --list-merge-at 'spec.args=replace' --list-merge-at 'spec.finalizers=prepend' --list-merge-at 'spec.env=append' --list-merge-at 'spec.hosts=index' --list-merge-at 'spec.containers=merge-by-key'
+
+f-log 'convert 39 : override test. check deletion directives (!delete , $patch , $retainKeys , $deleteFromPrimitiveList) in override file.'
+f-test-convert  sample37.yaml
+
+f-log "override 1 : check multiple --override-file , override file from stdin and --override-report option"
+f-test-override-files  sample38.yaml
+
+f-log "convert 40 : check --set , --set-string , --set-json and --set-file option with [key=value] and [index] path."
+f-test-convert  sample39.yaml --set spec.replicas=3 --set 'spec.template.spec.containers[name=app].image=nginx:1.25' --set 'spec.template.spec.containers[name=sidecar].args[1]=--c' --set 'spec.template.spec.containers[name=app].ports[containerPort=80,protocol=TCP].name=http' --set 'spec.template.spec.containers[name=new].image=busybox' --set 'spec.tolerations[1].key=gpu' --set-string 'metadata.labels.version=1.0' --set-json 'spec.template.spec.containers[name=app].resources={"limits":{"cpu":"100m"}}' --set-file 'data.script=sample39-script.sh'
+f-test-failure yamlsort -i sample39.yaml --set 'spec.template.spec.containers[*].image=busybox'
+f-test-failure yamlsort -i sample39.yaml --set-json 'spec.replicas={'
+f-test-convert  sample45.yaml --set 'metadata.annotations.a\.b/c=y' --set 'metadata.annotations.app\.kubernetes\.io/name=api' --set-string 'metadata.labels["app.kubernetes.io/version"]=1.0'
+
+f-log "convert 41 : check timestamp is output as written text."
+f-test-convert  sample40.yaml
+
+f-log "convert 42 : check leading comment block of document , and tags (!Ref , !!binary) are written."
+f-test-convert  sample41.yaml
+
+f-log "convert 43 : check --flow-style option with flow indicators in plain string. output is read back with --check."
+f-test-convert  sample42.yaml --flow-style
+f-test-success yamlsort --check --flow-style -i out2/sample42-out2.yaml
+
+f-log "override 2 : check override of array of arrays (not merged by key) is error."
+f-test-failure yamlsort -i sample43.yaml --override-file sample43-nested.yaml
+
+f-log 'convert 44 : check map key with "." in key path pattern , escaped with \. or ["key"] .'
+f-test-convert  sample44.yaml --quote-at 'metadata.annotations.*=double' --quote-at 'metadata.labels["app.kubernetes.io/version"]=single' --key-at 'metadata.annotations=deployment.kubernetes.io/revision' --sort-list 'spec.env\.vars=name' --merge-key 'spec.containers\.v1=id' --skip-key 'metadata.annotations.plain'
+
+f-log "convert 45 : check plain yes/on , 1:30 in input are read by yaml 1.1 resolver and kept plain."
+f-test-convert  sample46.yaml
+f-test-success sh -c 'yamlsort -i sample46.yaml --jsonoutput | grep -q "\"enabled\": true"'
+
+f-log "convert 46 : check comment only document is kept as null document with its comment."
+f-test-convert  sample47.yaml
+
+f-log "convert 47 : check %YAML directive is read as 1.1 only in document header. same text in content is not changed."
+f-test-convert  sample48.yaml
+f-test-success sh -c 'yamlsort -i sample48.yaml | grep -q "%YAML 1.2 in content"'
+
+f-log "convert 48 : check --set [index]=null sets null item , and error of --set into value of other type has key path."
+f-test-convert  sample49.yaml --set 'args[1]=null' --set 'ports[3]=8080'
+f-test-success sh -c 'yamlsort -i sample49.yaml --set ports.http=80 2>&1 | grep -q "can not override ports: !!seq with !!map"'
+
+f-log "convert 49 : check --sort-documents with map key with \".\" , escaped with \\. ."
+f-test-convert  sample50.yaml --sort-documents 'metadata.labels.app\.kubernetes\.io/name'
+
+f-log "check 1 : check --check option. sorted file is success, not sorted file is failure."
+f-test-success yamlsort --check -i out2/sample1-out2.yaml
+f-test-failure yamlsort --check -i sample1.yaml
+
+f-log "error 1 : check read error is printed once , without usage."
+ERROR_MESSAGE=$( yamlsort -i not-found.yaml 2>&1 )
+if [ "$ERROR_MESSAGE" = "Error: open not-found.yaml: no such file or directory" ] ; then
+    echo "SUCCESS"
+    TEST_SUCCESS_COUNT=$(( $TEST_SUCCESS_COUNT + 1 ))
+else
+    echo "$ERROR_MESSAGE"
+    echo "FAILURE"
+    TEST_FAILURE_COUNT=$(( $TEST_FAILURE_COUNT + 1 ))
+fi
+
+f-log "error 2 : check yaml parse error is printed once."
+ERROR_MESSAGE=$( echo "a: [" | yamlsort 2>&1 )
+if [ "$ERROR_MESSAGE" = "Error: yaml: line 1: did not find expected node content" ] ; then
+    echo "SUCCESS"
+    TEST_SUCCESS_COUNT=$(( $TEST_SUCCESS_COUNT + 1 ))
+else
+    echo "$ERROR_MESSAGE"
+    echo "FAILURE"
+    TEST_FAILURE_COUNT=$(( $TEST_FAILURE_COUNT + 1 ))
+fi
+
+f-log "diff 1 : check --diff option"
+f-test-diff  sample2.yaml
+
+f-log "files 1 : check file arguments , --recursive , --include , --exclude , --output-dir option"
+f-test-files
+
+f-log "config 1 : check .yamlsort.yaml , overrides and --print-config option"
+f-test-config
+
+f-log "config 2 : check file path in .yamlsort.yaml is relative to the directory of it"
+f-test-config-path
+
 f-log "TEST_SUCCESS_COUNT  $TEST_SUCCESS_COUNT  "
 f-log "TEST_FAILURE_COUNT  $TEST_FAILURE_COUNT  "